@@ -0,0 +1,125 @@
+package config
+
+import (
+	"helmvalues/pkg/compat"
+	"helmvalues/pkg/schema"
+	"helmvalues/pkg/schema/comments"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v4"
+)
+
+func NewCompatConfig() *CompatConfig {
+	cfg := standardViper()
+
+	return &CompatConfig{cfg}
+}
+
+type CompatConfig struct {
+	*viper.Viper
+}
+
+func (c *CompatConfig) LogLevel() (logrus.Level, error) {
+	return logrus.ParseLevel(c.GetString("log-level"))
+}
+
+func (c *CompatConfig) SchemaSource() (schema.Source, error) {
+	return schema.NewSource(c.GetString("schema"))
+}
+
+func (c *CompatConfig) AdditionalProperties() (schema.AdditionalPropertiesPolicy, error) {
+	return schema.NewAdditionalPropertiesPolicy(c.GetString("additional-properties"))
+}
+
+func (c *CompatConfig) TagSchemas() (map[string]*yaml.Node, error) {
+	return schema.ReadTagSchemas(c.GetString("tag-schemas"))
+}
+
+func (c *CompatConfig) CommentDialect() (comments.Dialect, error) {
+	return comments.NewDialect(c.GetString("comment-dialect"))
+}
+
+func (c *CompatConfig) UpdateLogger(logger *logrus.Logger) error {
+	level, err := c.LogLevel()
+	if err != nil {
+		return err
+	}
+
+	logger.SetLevel(level)
+	return nil
+}
+
+func (c *CompatConfig) BindFlags(cmd *cobra.Command) {
+	cmd.Flags().String("schema", "file", "schemas to compare (file, generate)")
+	c.BindPFlag("schema", cmd.Flags().Lookup("schema"))
+	c.BindEnv("schema")
+
+	cmd.Flags().String("log-level", "warn", "log level (debug, info, warn, error, fatal, panic)")
+	c.BindPFlag("log-level", cmd.Flags().Lookup("log-level"))
+	c.BindEnv("log-level")
+
+	cmd.Flags().String("tag-schemas", "", "path to yaml file mapping custom yaml tags to schemas")
+	c.BindPFlag("tag-schemas", cmd.Flags().Lookup("tag-schemas"))
+	c.BindEnv("tag-schemas")
+
+	cmd.Flags().Bool("compose-subcharts", false, "embed the schemas of chart dependencies found in the charts directory")
+	c.BindPFlag("compose-subcharts", cmd.Flags().Lookup("compose-subcharts"))
+	c.BindEnv("compose-subcharts")
+
+	cmd.Flags().String("additional-properties", "strict-except-empty", "when objects disallow undeclared keys (strict, lax, strict-except-empty)")
+	c.BindPFlag("additional-properties", cmd.Flags().Lookup("additional-properties"))
+	c.BindEnv("additional-properties")
+
+	cmd.Flags().String("comment-dialect", "native", "syntax of doc comments (native, helm-docs, bitnami)")
+	c.BindPFlag("comment-dialect", cmd.Flags().Lookup("comment-dialect"))
+	c.BindEnv("comment-dialect")
+
+	cmd.Flags().Bool("foot-comments", false, "append foot comments to the description of the value above them")
+	c.BindPFlag("foot-comments", cmd.Flags().Lookup("foot-comments"))
+	c.BindEnv("foot-comments")
+
+	cmd.Flags().String("detached-marker", comments.DefaultDetachedMarker, "prefix of comment paragraphs that aren't part of the doc comment below them")
+	c.BindPFlag("detached-marker", cmd.Flags().Lookup("detached-marker"))
+	c.BindEnv("detached-marker")
+}
+
+func (c *CompatConfig) ToPackageConfig() (*compat.Config, error) {
+	logLevel, err := c.LogLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	schemaSource, err := c.SchemaSource()
+	if err != nil {
+		return nil, err
+	}
+
+	tagSchemas, err := c.TagSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	additionalProperties, err := c.AdditionalProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	commentDialect, err := c.CommentDialect()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &compat.Config{
+		Schema:               schemaSource,
+		TagSchemas:           tagSchemas,
+		ComposeSubcharts:     c.GetBool("compose-subcharts"),
+		AdditionalProperties: additionalProperties,
+		CommentDialect:       commentDialect,
+		FootComments:         c.GetBool("foot-comments"),
+		DetachedMarker:       c.GetString("detached-marker"),
+		LogLevel:             logLevel,
+	}
+	return config, nil
+}
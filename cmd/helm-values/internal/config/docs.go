@@ -3,12 +3,15 @@ package config
 import (
 	"helmvalues/pkg/docs"
 	"helmvalues/pkg/docs/templates"
+	"helmvalues/pkg/schema"
+	"helmvalues/pkg/schema/comments"
 	"path/filepath"
 
 	"github.com/samber/mo"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v4"
 )
 
 func NewDocsConfig() *DocsConfig {
@@ -68,6 +71,14 @@ func (c *DocsConfig) Output() mo.Option[string] {
 	return mo.Some(c.GetString("output"))
 }
 
+func (c *DocsConfig) TagSchemas() (map[string]*yaml.Node, error) {
+	return schema.ReadTagSchemas(c.GetString("tag-schemas"))
+}
+
+func (c *DocsConfig) CommentDialect() (comments.Dialect, error) {
+	return comments.NewDialect(c.GetString("comment-dialect"))
+}
+
 func (c *DocsConfig) UpdateLogger(logger *logrus.Logger) error {
 	level, err := c.LogLevel()
 	if err != nil {
@@ -91,6 +102,18 @@ func (c *DocsConfig) BindFlags(cmd *cobra.Command) {
 	c.BindPFlag("dry-run", cmd.Flags().Lookup("dry-run"))
 	c.BindEnv("dry-run")
 
+	cmd.Flags().Bool("check", false, "fail if generated files differ from the files on disk, without writing")
+	c.BindPFlag("check", cmd.Flags().Lookup("check"))
+	c.BindEnv("check")
+
+	cmd.Flags().Bool("diff", false, "print a unified diff of the changes to each output file")
+	c.BindPFlag("diff", cmd.Flags().Lookup("diff"))
+	c.BindEnv("diff")
+
+	cmd.Flags().Bool("color", false, "color the diff output")
+	c.BindPFlag("color", cmd.Flags().Lookup("color"))
+	c.BindEnv("color")
+
 	cmd.Flags().String("log-level", "warn", "log level (debug, info, warn, error, fatal, panic)")
 	c.BindPFlag("log-level", cmd.Flags().Lookup("log-level"))
 	c.BindEnv("log-level")
@@ -118,6 +141,22 @@ func (c *DocsConfig) BindFlags(cmd *cobra.Command) {
 	cmd.Flags().String("extra-templates", "", "glob path to extra templates")
 	c.BindPFlag("extra-templates", cmd.Flags().Lookup("extra-templates"))
 	c.BindEnv("extra-templates")
+
+	cmd.Flags().String("tag-schemas", "", "path to yaml file mapping custom yaml tags to schemas")
+	c.BindPFlag("tag-schemas", cmd.Flags().Lookup("tag-schemas"))
+	c.BindEnv("tag-schemas")
+
+	cmd.Flags().String("comment-dialect", "native", "syntax of doc comments (native, helm-docs, bitnami)")
+	c.BindPFlag("comment-dialect", cmd.Flags().Lookup("comment-dialect"))
+	c.BindEnv("comment-dialect")
+
+	cmd.Flags().Bool("foot-comments", false, "append foot comments to the description of the value above them")
+	c.BindPFlag("foot-comments", cmd.Flags().Lookup("foot-comments"))
+	c.BindEnv("foot-comments")
+
+	cmd.Flags().String("detached-marker", comments.DefaultDetachedMarker, "prefix of comment paragraphs that aren't part of the doc comment below them")
+	c.BindPFlag("detached-marker", cmd.Flags().Lookup("detached-marker"))
+	c.BindEnv("detached-marker")
 }
 
 func (c *DocsConfig) ToPackageConfig() (*docs.Config, error) {
@@ -141,17 +180,34 @@ func (c *DocsConfig) ToPackageConfig() (*docs.Config, error) {
 		return nil, err
 	}
 
+	tagSchemas, err := c.TagSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	commentDialect, err := c.CommentDialect()
+	if err != nil {
+		return nil, err
+	}
+
 	config := &docs.Config{
 		LogLevel:       logLevel,
 		StdOut:         c.GetBool("stdout"),
 		Strict:         c.GetBool("strict"),
 		DryRun:         c.GetBool("dry-run"),
+		Check:          c.GetBool("check"),
+		Diff:           c.GetBool("diff"),
+		Color:          c.GetBool("color"),
 		UseDefault:     c.UseDefault(),
 		Output:         c.Output(),
 		Template:       c.GetString("template"),
 		ExtraTemplates: extraTemplates,
 		Markup:         markup,
 		Order:          valuesOrder,
+		TagSchemas:     tagSchemas,
+		CommentDialect: commentDialect,
+		FootComments:   c.GetBool("foot-comments"),
+		DetachedMarker: c.GetString("detached-marker"),
 	}
 	return config, nil
 }
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"helmvalues/pkg/migrate"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewMigrateConfig() *MigrateConfig {
+	cfg := standardViper()
+
+	return &MigrateConfig{cfg}
+}
+
+type MigrateConfig struct {
+	*viper.Viper
+}
+
+func (c *MigrateConfig) LogLevel() (logrus.Level, error) {
+	return logrus.ParseLevel(c.GetString("log-level"))
+}
+
+func (c *MigrateConfig) UpdateLogger(logger *logrus.Logger) error {
+	level, err := c.LogLevel()
+	if err != nil {
+		return err
+	}
+
+	logger.SetLevel(level)
+	return nil
+}
+
+func (c *MigrateConfig) BindFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("stdout", false, "write to stdout")
+	c.BindPFlag("stdout", cmd.Flags().Lookup("stdout"))
+	c.BindEnv("stdout")
+
+	cmd.Flags().Bool("dry-run", false, "don't write changes to disk")
+	c.BindPFlag("dry-run", cmd.Flags().Lookup("dry-run"))
+	c.BindEnv("dry-run")
+
+	cmd.Flags().String("log-level", "warn", "log level (debug, info, warn, error, fatal, panic)")
+	c.BindPFlag("log-level", cmd.Flags().Lookup("log-level"))
+	c.BindEnv("log-level")
+}
+
+func (c *MigrateConfig) ToPackageConfig() (*migrate.Config, error) {
+	logLevel, err := c.LogLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &migrate.Config{
+		StdOut:   c.GetBool("stdout"),
+		DryRun:   c.GetBool("dry-run"),
+		LogLevel: logLevel,
+	}
+	return config, nil
+}
@@ -2,10 +2,14 @@ package config
 
 import (
 	"helmvalues/pkg/schema"
+	"helmvalues/pkg/schema/comments"
+	"os"
+	"path/filepath"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v4"
 )
 
 func NewSchemaConfig() *SchemaConfig {
@@ -22,6 +26,30 @@ func (c *SchemaConfig) LogLevel() (logrus.Level, error) {
 	return logrus.ParseLevel(c.GetString("log-level"))
 }
 
+func (c *SchemaConfig) AdditionalProperties() (schema.AdditionalPropertiesPolicy, error) {
+	return schema.NewAdditionalPropertiesPolicy(c.GetString("additional-properties"))
+}
+
+func (c *SchemaConfig) TagSchemas() (map[string]*yaml.Node, error) {
+	return schema.ReadTagSchemas(c.GetString("tag-schemas"))
+}
+
+func (c *SchemaConfig) RefCacheDir() (string, error) {
+	if c.IsSet("ref-cache") {
+		return filepath.Abs(c.GetString("ref-cache"))
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "helm-values", "refs"), nil
+}
+
+func (c *SchemaConfig) CommentDialect() (comments.Dialect, error) {
+	return comments.NewDialect(c.GetString("comment-dialect"))
+}
+
 func (c *SchemaConfig) UpdateLogger(logger *logrus.Logger) error {
 	level, err := c.LogLevel()
 	if err != nil {
@@ -45,6 +73,18 @@ func (c *SchemaConfig) BindFlags(cmd *cobra.Command) {
 	c.BindPFlag("dry-run", cmd.Flags().Lookup("dry-run"))
 	c.BindEnv("dry-run")
 
+	cmd.Flags().Bool("check", false, "fail if generated files differ from the files on disk, without writing")
+	c.BindPFlag("check", cmd.Flags().Lookup("check"))
+	c.BindEnv("check")
+
+	cmd.Flags().Bool("diff", false, "print a unified diff of the changes to each output file")
+	c.BindPFlag("diff", cmd.Flags().Lookup("diff"))
+	c.BindEnv("diff")
+
+	cmd.Flags().Bool("color", false, "color the diff output")
+	c.BindPFlag("color", cmd.Flags().Lookup("color"))
+	c.BindEnv("color")
+
 	cmd.Flags().String("log-level", "warn", "log level (debug, info, warn, error, fatal, panic)")
 	c.BindPFlag("log-level", cmd.Flags().Lookup("log-level"))
 	c.BindEnv("log-level")
@@ -52,6 +92,42 @@ func (c *SchemaConfig) BindFlags(cmd *cobra.Command) {
 	cmd.Flags().Bool("write-modeline", true, "write modeline to values file")
 	c.BindPFlag("write-modeline", cmd.Flags().Lookup("write-modeline"))
 	c.BindEnv("write-modeline")
+
+	cmd.Flags().Bool("anchor-definitions", false, "emit yaml anchors as shared definitions")
+	c.BindPFlag("anchor-definitions", cmd.Flags().Lookup("anchor-definitions"))
+	c.BindEnv("anchor-definitions")
+
+	cmd.Flags().String("tag-schemas", "", "path to yaml file mapping custom yaml tags to schemas")
+	c.BindPFlag("tag-schemas", cmd.Flags().Lookup("tag-schemas"))
+	c.BindEnv("tag-schemas")
+
+	cmd.Flags().Bool("bundle-refs", false, "copy $ref targets into definitions so the schema works offline")
+	c.BindPFlag("bundle-refs", cmd.Flags().Lookup("bundle-refs"))
+	c.BindEnv("bundle-refs")
+
+	cmd.Flags().String("ref-cache", "", "directory of cached $ref documents, stored by host and path (defaults to the user cache dir)")
+	c.BindPFlag("ref-cache", cmd.Flags().Lookup("ref-cache"))
+	c.BindEnv("ref-cache")
+
+	cmd.Flags().Bool("compose-subcharts", false, "embed the schemas of chart dependencies found in the charts directory")
+	c.BindPFlag("compose-subcharts", cmd.Flags().Lookup("compose-subcharts"))
+	c.BindEnv("compose-subcharts")
+
+	cmd.Flags().String("additional-properties", "strict-except-empty", "when objects disallow undeclared keys (strict, lax, strict-except-empty)")
+	c.BindPFlag("additional-properties", cmd.Flags().Lookup("additional-properties"))
+	c.BindEnv("additional-properties")
+
+	cmd.Flags().String("comment-dialect", "native", "syntax of doc comments (native, helm-docs, bitnami)")
+	c.BindPFlag("comment-dialect", cmd.Flags().Lookup("comment-dialect"))
+	c.BindEnv("comment-dialect")
+
+	cmd.Flags().Bool("foot-comments", false, "append foot comments to the description of the value above them")
+	c.BindPFlag("foot-comments", cmd.Flags().Lookup("foot-comments"))
+	c.BindEnv("foot-comments")
+
+	cmd.Flags().String("detached-marker", comments.DefaultDetachedMarker, "prefix of comment paragraphs that aren't part of the doc comment below them")
+	c.BindPFlag("detached-marker", cmd.Flags().Lookup("detached-marker"))
+	c.BindEnv("detached-marker")
 }
 
 func (c *SchemaConfig) ToPackageConfig() (*schema.Config, error) {
@@ -60,12 +136,44 @@ func (c *SchemaConfig) ToPackageConfig() (*schema.Config, error) {
 		return nil, err
 	}
 
+	tagSchemas, err := c.TagSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	refCacheDir, err := c.RefCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	additionalProperties, err := c.AdditionalProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	commentDialect, err := c.CommentDialect()
+	if err != nil {
+		return nil, err
+	}
+
 	config := &schema.Config{
-		StdOut:        c.GetBool("stdout"),
-		Strict:        c.GetBool("strict"),
-		DryRun:        c.GetBool("dry-run"),
-		WriteModeline: c.GetBool("write-modeline"),
-		LogLevel:      logLevel,
+		StdOut:               c.GetBool("stdout"),
+		Strict:               c.GetBool("strict"),
+		DryRun:               c.GetBool("dry-run"),
+		Check:                c.GetBool("check"),
+		Diff:                 c.GetBool("diff"),
+		Color:                c.GetBool("color"),
+		WriteModeline:        c.GetBool("write-modeline"),
+		AnchorDefinitions:    c.GetBool("anchor-definitions"),
+		TagSchemas:           tagSchemas,
+		BundleRefs:           c.GetBool("bundle-refs"),
+		RefCacheDir:          refCacheDir,
+		ComposeSubcharts:     c.GetBool("compose-subcharts"),
+		AdditionalProperties: additionalProperties,
+		CommentDialect:       commentDialect,
+		FootComments:         c.GetBool("foot-comments"),
+		DetachedMarker:       c.GetString("detached-marker"),
+		LogLevel:             logLevel,
 	}
 	return config, nil
 }
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"helmvalues/pkg/schema"
+	"helmvalues/pkg/schema/comments"
+	"helmvalues/pkg/validate"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v4"
+)
+
+func NewValidateConfig() *ValidateConfig {
+	cfg := standardViper()
+
+	return &ValidateConfig{cfg}
+}
+
+type ValidateConfig struct {
+	*viper.Viper
+}
+
+func (c *ValidateConfig) LogLevel() (logrus.Level, error) {
+	return logrus.ParseLevel(c.GetString("log-level"))
+}
+
+func (c *ValidateConfig) SchemaSource() (schema.Source, error) {
+	return schema.NewSource(c.GetString("schema"))
+}
+
+func (c *ValidateConfig) AdditionalProperties() (schema.AdditionalPropertiesPolicy, error) {
+	return schema.NewAdditionalPropertiesPolicy(c.GetString("additional-properties"))
+}
+
+func (c *ValidateConfig) TagSchemas() (map[string]*yaml.Node, error) {
+	return schema.ReadTagSchemas(c.GetString("tag-schemas"))
+}
+
+func (c *ValidateConfig) CommentDialect() (comments.Dialect, error) {
+	return comments.NewDialect(c.GetString("comment-dialect"))
+}
+
+func (c *ValidateConfig) UpdateLogger(logger *logrus.Logger) error {
+	level, err := c.LogLevel()
+	if err != nil {
+		return err
+	}
+
+	logger.SetLevel(level)
+	return nil
+}
+
+func (c *ValidateConfig) BindFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayP("values", "f", []string{}, "values file to validate (can be repeated)")
+	c.BindPFlag("values", cmd.Flags().Lookup("values"))
+	c.BindEnv("values")
+
+	cmd.Flags().Bool("merge", false, "validate the values coalesced with the chart's values and set flags, as helm does")
+	c.BindPFlag("merge", cmd.Flags().Lookup("merge"))
+	c.BindEnv("merge")
+
+	cmd.Flags().StringArray("set", []string{}, "set values like helm's --set (requires --merge)")
+	c.BindPFlag("set", cmd.Flags().Lookup("set"))
+	c.BindEnv("set")
+
+	cmd.Flags().StringArray("set-string", []string{}, "set string values like helm's --set-string (requires --merge)")
+	c.BindPFlag("set-string", cmd.Flags().Lookup("set-string"))
+	c.BindEnv("set-string")
+
+	cmd.Flags().StringArray("set-json", []string{}, "set json values like helm's --set-json (requires --merge)")
+	c.BindPFlag("set-json", cmd.Flags().Lookup("set-json"))
+	c.BindEnv("set-json")
+
+	cmd.Flags().String("schema", "file", "schema to validate against (file, generate)")
+	c.BindPFlag("schema", cmd.Flags().Lookup("schema"))
+	c.BindEnv("schema")
+
+	cmd.Flags().String("log-level", "warn", "log level (debug, info, warn, error, fatal, panic)")
+	c.BindPFlag("log-level", cmd.Flags().Lookup("log-level"))
+	c.BindEnv("log-level")
+
+	cmd.Flags().String("tag-schemas", "", "path to yaml file mapping custom yaml tags to schemas")
+	c.BindPFlag("tag-schemas", cmd.Flags().Lookup("tag-schemas"))
+	c.BindEnv("tag-schemas")
+
+	cmd.Flags().Bool("compose-subcharts", false, "embed the schemas of chart dependencies found in the charts directory")
+	c.BindPFlag("compose-subcharts", cmd.Flags().Lookup("compose-subcharts"))
+	c.BindEnv("compose-subcharts")
+
+	cmd.Flags().String("additional-properties", "strict-except-empty", "when objects disallow undeclared keys (strict, lax, strict-except-empty)")
+	c.BindPFlag("additional-properties", cmd.Flags().Lookup("additional-properties"))
+	c.BindEnv("additional-properties")
+
+	cmd.Flags().String("comment-dialect", "native", "syntax of doc comments (native, helm-docs, bitnami)")
+	c.BindPFlag("comment-dialect", cmd.Flags().Lookup("comment-dialect"))
+	c.BindEnv("comment-dialect")
+
+	cmd.Flags().Bool("foot-comments", false, "append foot comments to the description of the value above them")
+	c.BindPFlag("foot-comments", cmd.Flags().Lookup("foot-comments"))
+	c.BindEnv("foot-comments")
+
+	cmd.Flags().String("detached-marker", comments.DefaultDetachedMarker, "prefix of comment paragraphs that aren't part of the doc comment below them")
+	c.BindPFlag("detached-marker", cmd.Flags().Lookup("detached-marker"))
+	c.BindEnv("detached-marker")
+}
+
+func (c *ValidateConfig) ToPackageConfig() (*validate.Config, error) {
+	logLevel, err := c.LogLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	schemaSource, err := c.SchemaSource()
+	if err != nil {
+		return nil, err
+	}
+
+	tagSchemas, err := c.TagSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	additionalProperties, err := c.AdditionalProperties()
+	if err != nil {
+		return nil, err
+	}
+
+	commentDialect, err := c.CommentDialect()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &validate.Config{
+		ValuesFiles:          c.GetStringSlice("values"),
+		Merge:                c.GetBool("merge"),
+		SetValues:            c.GetStringSlice("set"),
+		SetStringValues:      c.GetStringSlice("set-string"),
+		SetJSONValues:        c.GetStringSlice("set-json"),
+		Schema:               schemaSource,
+		TagSchemas:           tagSchemas,
+		ComposeSubcharts:     c.GetBool("compose-subcharts"),
+		AdditionalProperties: additionalProperties,
+		CommentDialect:       commentDialect,
+		FootComments:         c.GetBool("foot-comments"),
+		DetachedMarker:       c.GetString("detached-marker"),
+		LogLevel:             logLevel,
+	}
+	return config, nil
+}
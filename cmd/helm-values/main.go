@@ -4,8 +4,11 @@ import (
 	"os"
 
 	"helmvalues/cmd/helm-values/internal/config"
+	"helmvalues/pkg/compat"
 	"helmvalues/pkg/docs"
+	"helmvalues/pkg/migrate"
 	"helmvalues/pkg/schema"
+	"helmvalues/pkg/validate"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -29,6 +32,9 @@ func Program(logger *logrus.Logger) *cobra.Command {
 	cmd.CompletionOptions.DisableDefaultCmd = true
 	cmd.AddCommand(Schema(logger))
 	cmd.AddCommand(Docs(logger))
+	cmd.AddCommand(Migrate(logger))
+	cmd.AddCommand(Validate(logger))
+	cmd.AddCommand(Compat(logger))
 	return cmd
 }
 
@@ -47,6 +53,8 @@ func Schema(logger *logrus.Logger) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			// errors past this point aren't usage errors, so usage isn't printed
+			cmd.SilenceUsage = true
 			return schema.GenerateSchema(logger, schemaCfg, args)
 		},
 	}
@@ -72,6 +80,8 @@ func Docs(logger *logrus.Logger) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			// errors past this point aren't usage errors, so usage isn't printed
+			cmd.SilenceUsage = true
 			return docs.GenerateDocs(logger, docsCfg, args)
 		},
 	}
@@ -80,3 +90,84 @@ func Docs(logger *logrus.Logger) *cobra.Command {
 
 	return cmd
 }
+
+func Migrate(logger *logrus.Logger) *cobra.Command {
+	cfg := config.NewMigrateConfig()
+
+	cmd := &cobra.Command{
+		Use:   "migrate [flags] chart_dir [...chart_dir]",
+		Short: "Rewrite helm-schema comments as native comments",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cfg.UpdateLogger(logger); err != nil {
+				return err
+			}
+
+			migrateCfg, err := cfg.ToPackageConfig()
+			if err != nil {
+				return err
+			}
+			// errors past this point aren't usage errors, so usage isn't printed
+			cmd.SilenceUsage = true
+			return migrate.MigrateComments(logger, migrateCfg, args)
+		},
+	}
+
+	cfg.BindFlags(cmd)
+
+	return cmd
+}
+
+func Validate(logger *logrus.Logger) *cobra.Command {
+	cfg := config.NewValidateConfig()
+
+	cmd := &cobra.Command{
+		Use:   "validate [flags] chart_dir [...chart_dir]",
+		Short: "Validate values files against the values schema",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cfg.UpdateLogger(logger); err != nil {
+				return err
+			}
+
+			validateCfg, err := cfg.ToPackageConfig()
+			if err != nil {
+				return err
+			}
+			// errors past this point aren't usage errors, so usage isn't printed
+			cmd.SilenceUsage = true
+			return validate.ValidateValues(logger, validateCfg, args)
+		},
+	}
+
+	cfg.BindFlags(cmd)
+
+	return cmd
+}
+
+func Compat(logger *logrus.Logger) *cobra.Command {
+	cfg := config.NewCompatConfig()
+
+	cmd := &cobra.Command{
+		Use:   "compat [flags] old_chart_dir new_chart_dir",
+		Short: "Compare values schemas for breaking changes",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cfg.UpdateLogger(logger); err != nil {
+				return err
+			}
+
+			compatCfg, err := cfg.ToPackageConfig()
+			if err != nil {
+				return err
+			}
+			// errors past this point aren't usage errors, so usage isn't printed
+			cmd.SilenceUsage = true
+			return compat.CompareCharts(logger, compatCfg, args[0], args[1])
+		},
+	}
+
+	cfg.BindFlags(cmd)
+
+	return cmd
+}
@@ -49,6 +49,10 @@ func (p *Chart) SchemaFilePath() string {
 	return fmt.Sprintf("%s/values.schema.json", p.rootPath)
 }
 
+func (p *Chart) DefinitionsFilePath() string {
+	return fmt.Sprintf("%s/values.definitions.yaml", p.rootPath)
+}
+
 func (p *Chart) ReadmeMdFilePath() string {
 	return fmt.Sprintf("%s/README.md", p.rootPath)
 }
@@ -66,6 +70,8 @@ func (p *Chart) ReadmeRstTemplateFilePath() string {
 }
 
 type ChartDetails struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
+	Name         string             `yaml:"name"`
+	Description  string             `yaml:"description"`
+	Version      string             `yaml:"version"`
+	Dependencies []*ChartDependency `yaml:"dependencies"`
 }
@@ -0,0 +1,204 @@
+package charts
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+var ErrSubchartNotFound = errors.New("subchart not found")
+
+type ChartDependency struct {
+	Name       string   `yaml:"name"`
+	Version    string   `yaml:"version"`
+	Repository string   `yaml:"repository"`
+	Alias      string   `yaml:"alias"`
+	Condition  string   `yaml:"condition"`
+	Tags       []string `yaml:"tags"`
+}
+
+// ValuesKey returns the key the dependency's values are nested under in the
+// parent chart's values.
+func (d *ChartDependency) ValuesKey() string {
+	if d.Alias != "" {
+		return d.Alias
+	}
+	return d.Name
+}
+
+// Conditions returns the value paths of the dependency's condition, which
+// helm evaluates in order until one resolves.
+func (d *ChartDependency) Conditions() []string {
+	conditions := []string{}
+	for _, condition := range strings.Split(d.Condition, ",") {
+		if condition = strings.TrimSpace(condition); condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+	return conditions
+}
+
+func (p *Chart) SubchartsDirPath() string {
+	return fmt.Sprintf("%s/charts", p.rootPath)
+}
+
+// LoadSubchart locates the dependency in the chart's charts directory, either
+// as a chart directory or a packaged .tgz archive. Archives are extracted to a
+// temporary directory which is removed by the returned cleanup func.
+func (p *Chart) LoadSubchart(dep *ChartDependency) (*Chart, func(), error) {
+	noop := func() {}
+
+	entries, err := os.ReadDir(p.SubchartsDirPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, noop, ErrSubchartNotFound
+	}
+	if err != nil {
+		return nil, noop, err
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(p.SubchartsDirPath(), entry.Name())
+
+		if entry.IsDir() {
+			subchart, err := NewChart(entryPath)
+			if err != nil || subchart.Details.Name != dep.Name {
+				continue
+			}
+			return subchart, noop, nil
+		}
+
+		if !strings.HasSuffix(entry.Name(), ".tgz") {
+			continue
+		}
+
+		details, err := readArchiveDetails(entryPath)
+		if err != nil || details.Name != dep.Name {
+			continue
+		}
+
+		tmpDir, err := os.MkdirTemp("", "helm-values-subchart-")
+		if err != nil {
+			return nil, noop, err
+		}
+		cleanup := func() { os.RemoveAll(tmpDir) }
+
+		chartDir, err := extractArchive(entryPath, tmpDir)
+		if err != nil {
+			cleanup()
+			return nil, noop, err
+		}
+
+		subchart, err := NewChart(chartDir)
+		if err != nil {
+			cleanup()
+			return nil, noop, err
+		}
+		return subchart, cleanup, nil
+	}
+
+	return nil, noop, ErrSubchartNotFound
+}
+
+// readArchiveDetails reads the Chart.yaml at the top level of a packaged chart.
+func readArchiveDetails(archivePath string) (*ChartDetails, error) {
+	var details *ChartDetails
+	err := walkArchive(archivePath, func(header *tar.Header, r io.Reader) (bool, error) {
+		parts := strings.Split(filepath.ToSlash(header.Name), "/")
+		if len(parts) != 2 || parts[1] != "Chart.yaml" {
+			return false, nil
+		}
+
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return false, err
+		}
+
+		details = &ChartDetails{}
+		return true, yaml.Unmarshal(content, details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, fmt.Errorf("no Chart.yaml in %s", archivePath)
+	}
+
+	return details, nil
+}
+
+// extractArchive extracts a packaged chart into the destination directory,
+// returning the path of the extracted chart directory.
+func extractArchive(archivePath string, dest string) (string, error) {
+	chartDir := ""
+	err := walkArchive(archivePath, func(header *tar.Header, r io.Reader) (bool, error) {
+		target := filepath.Join(dest, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return false, fmt.Errorf("illegal path in %s: %s", archivePath, header.Name)
+		}
+
+		if chartDir == "" {
+			topDir := strings.Split(filepath.ToSlash(header.Name), "/")[0]
+			chartDir = filepath.Join(dest, topDir)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			return false, os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return false, err
+			}
+			f, err := os.Create(target)
+			if err != nil {
+				return false, err
+			}
+			defer f.Close()
+
+			_, err = io.Copy(f, r)
+			return false, err
+		default:
+			return false, nil
+		}
+	})
+
+	return chartDir, err
+}
+
+// walkArchive calls fn for each entry in the gzipped tar archive until fn
+// returns true or an error.
+func walkArchive(archivePath string, fn func(header *tar.Header, r io.Reader) (bool, error)) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		done, err := fn(header, tr)
+		if err != nil || done {
+			return err
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	colorReset = "\033[0m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// FileChange is the content of a generated file on disk and the content it
+// would be written with. Before is empty if the file doesn't exist yet.
+type FileChange struct {
+	Path   string
+	Exists bool
+	Before string
+	After  string
+}
+
+// NewFileChange returns the change of writing the content to the file at path.
+func NewFileChange(path string, content string) (FileChange, error) {
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return FileChange{Path: path, After: content}, nil
+	}
+	if err != nil {
+		return FileChange{}, err
+	}
+	return FileChange{Path: path, Exists: true, Before: string(existing), After: content}, nil
+}
+
+func (c FileChange) Changed() bool {
+	return !c.Exists || c.Before != c.After
+}
+
+// WriteDiffs writes a unified diff of each changed file, under a header naming
+// the chart. Nothing is written when no files changed.
+func WriteDiffs(w io.Writer, chartName string, changes []FileChange, color bool) error {
+	diffs := []string{}
+	for _, change := range changes {
+		if !change.Changed() {
+			continue
+		}
+
+		fromFile := change.Path
+		if !change.Exists {
+			fromFile = "/dev/null"
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(change.Before),
+			B:        splitLines(change.After),
+			FromFile: fromFile,
+			ToFile:   change.Path,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			// an empty file was created
+			diff = fmt.Sprintf("--- %s\n+++ %s\n", fromFile, change.Path)
+		}
+		diffs = append(diffs, diff)
+	}
+
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	header := fmt.Sprintf("==> %s <==\n", chartName)
+	if color {
+		header = colorBold + strings.TrimSuffix(header, "\n") + colorReset + "\n"
+	}
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	for _, diff := range diffs {
+		if color {
+			diff = colorize(diff)
+		}
+		if _, err := io.WriteString(w, diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// noNewlineMarker follows a last line without a line ending, as it does in
+// the output of diff.
+const noNewlineMarker = `\ No newline at end of file`
+
+// splitLines splits the content into lines which keep their line endings. A
+// last line without a line ending is given one followed by the marker line, so
+// adding or removing the final newline diffs as a change to the last line.
+func splitLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n" + noNewlineMarker + "\n"
+	return lines
+}
+
+// colorize colors the lines of a single file's unified diff the way git does.
+func colorize(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		if text == "" {
+			continue
+		}
+
+		var color string
+		switch {
+		case i < 2 && (strings.HasPrefix(text, "---") || strings.HasPrefix(text, "+++")):
+			color = colorBold
+		case strings.HasPrefix(text, "@@"):
+			color = colorCyan
+		case strings.HasPrefix(text, "-"):
+			color = colorRed
+		case strings.HasPrefix(text, "+"):
+			color = colorGreen
+		default:
+			continue
+		}
+		lines[i] = color + text + colorReset + strings.TrimPrefix(line, text)
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDiffs(t *testing.T) {
+	t.Run("changed and new files are diffed under the chart name", func(tt *testing.T) {
+		changes := []FileChange{
+			{Path: "chart/values.schema.json", Exists: true, Before: "{\n  \"a\": 1\n}", After: "{\n  \"a\": 2\n}"},
+			{Path: "chart/values.yaml", Exists: true, Before: "a: 1\n", After: "a: 1\n"},
+			{Path: "chart/README.md", After: "# chart\n"},
+		}
+
+		buf := &bytes.Buffer{}
+		require.NoError(tt, WriteDiffs(buf, "chart", changes, false))
+		assert.Equal(tt, "==> chart <==\n"+
+			"--- chart/values.schema.json\n+++ chart/values.schema.json\n@@ -1,3 +1,3 @@\n {\n-  \"a\": 1\n+  \"a\": 2\n }\n\\ No newline at end of file\n"+
+			"--- /dev/null\n+++ chart/README.md\n@@ -0,0 +1 @@\n+# chart\n",
+			buf.String())
+	})
+
+	t.Run("nothing is written without changes", func(tt *testing.T) {
+		buf := &bytes.Buffer{}
+		changes := []FileChange{{Path: "chart/values.yaml", Exists: true, Before: "a: 1\n", After: "a: 1\n"}}
+		require.NoError(tt, WriteDiffs(buf, "chart", changes, false))
+		assert.Empty(tt, buf.String())
+	})
+
+	t.Run("final newline changes are marked", func(tt *testing.T) {
+		buf := &bytes.Buffer{}
+		changes := []FileChange{{Path: "values.yaml", Exists: true, Before: "a: 1\nb: 2", After: "a: 1\nb: 2\n"}}
+		require.NoError(tt, WriteDiffs(buf, "chart", changes, false))
+		assert.Equal(tt, "==> chart <==\n"+
+			"--- values.yaml\n+++ values.yaml\n@@ -1,2 +1,2 @@\n a: 1\n-b: 2\n\\ No newline at end of file\n+b: 2\n",
+			buf.String())
+	})
+
+	t.Run("diff lines are colored", func(tt *testing.T) {
+		buf := &bytes.Buffer{}
+		changes := []FileChange{{Path: "values.yaml", Exists: true, Before: "a: 1\n", After: "a: 2\n"}}
+		require.NoError(tt, WriteDiffs(buf, "chart", changes, true))
+		assert.Contains(tt, buf.String(), colorRed+"-a: 1"+colorReset+"\n")
+		assert.Contains(tt, buf.String(), colorGreen+"+a: 2"+colorReset+"\n")
+	})
+}
@@ -0,0 +1,456 @@
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"helmvalues/pkg"
+	"slices"
+	"strings"
+)
+
+// Bump is the part of the chart version a change requires to be bumped.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	// BumpPatch is required by changes to the docs of a value
+	BumpPatch
+	// BumpMinor is required by changes which keep existing values valid
+	BumpMinor
+	// BumpMajor is required by changes which can make existing values invalid,
+	// or change what they deploy
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// Change is a difference between the old and new schema of a value.
+type Change struct {
+	Path    string
+	Message string
+	Bump    Bump
+}
+
+// Breaking reports whether values which were valid before the change may be
+// invalid after it, or deploy something different.
+func (c Change) Breaking() bool {
+	return c.Bump == BumpMajor
+}
+
+func (c Change) String() string {
+	label := "non-breaking"
+	if c.Breaking() {
+		label = "breaking"
+	}
+
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return fmt.Sprintf("%s: %s: %s", label, path, c.Message)
+}
+
+// RequiredBump returns the largest bump required by the changes.
+func RequiredBump(changes []Change) Bump {
+	bump := BumpNone
+	for _, change := range changes {
+		bump = max(bump, change.Bump)
+	}
+	return bump
+}
+
+// Compare returns the changes between the old and new schema, in the order of
+// the old schema's properties followed by the properties added to the new
+// schema. References to local definitions are compared as the definitions.
+func Compare(oldRoot *pkg.JsonSchema, newRoot *pkg.JsonSchema) []Change {
+	c := &comparison{
+		oldRoot:  oldRoot,
+		newRoot:  newRoot,
+		changes:  []Change{},
+		visiting: map[[2]*pkg.JsonSchema]bool{},
+	}
+	c.compare(oldRoot, newRoot, []string{})
+	return c.changes
+}
+
+type comparison struct {
+	oldRoot  *pkg.JsonSchema
+	newRoot  *pkg.JsonSchema
+	changes  []Change
+	visiting map[[2]*pkg.JsonSchema]bool
+}
+
+func (c *comparison) compare(oldSchema *pkg.JsonSchema, newSchema *pkg.JsonSchema, path []string) {
+	oldSchema, oldRef := resolve(c.oldRoot, oldSchema)
+	newSchema, newRef := resolve(c.newRoot, newSchema)
+	if oldRef != newRef {
+		c.add(path, BumpMajor, "$ref changed from %q to %q", oldRef, newRef)
+		return
+	}
+
+	// recursive definitions are compared once
+	pair := [2]*pkg.JsonSchema{oldSchema, newSchema}
+	if c.visiting[pair] {
+		return
+	}
+	c.visiting[pair] = true
+	defer delete(c.visiting, pair)
+
+	c.compareTypes(oldSchema, newSchema, path)
+	c.compareEnums(oldSchema, newSchema, path)
+	c.compareConstraints(oldSchema, newSchema, path)
+	c.compareAnnotations(oldSchema, newSchema, path)
+	c.compareProperties(oldSchema, newSchema, path)
+
+	oldItems, newItems := pkg.KeywordSchema(oldSchema.Items), pkg.KeywordSchema(newSchema.Items)
+	if oldItems != nil || newItems != nil {
+		c.compare(orEmpty(oldItems), orEmpty(newItems), append(slices.Clone(path), "[]"))
+	}
+}
+
+func (c *comparison) compareTypes(oldSchema *pkg.JsonSchema, newSchema *pkg.JsonSchema, path []string) {
+	narrowed := !acceptsTypes(newSchema.Type, oldSchema.Type)
+	widened := !acceptsTypes(oldSchema.Type, newSchema.Type)
+
+	switch {
+	case narrowed:
+		c.add(path, BumpMajor, "type narrowed from %s to %s", typeLabel(oldSchema.Type), typeLabel(newSchema.Type))
+	case widened:
+		c.add(path, BumpMinor, "type widened from %s to %s", typeLabel(oldSchema.Type), typeLabel(newSchema.Type))
+	}
+}
+
+func (c *comparison) compareEnums(oldSchema *pkg.JsonSchema, newSchema *pkg.JsonSchema, path []string) {
+	switch {
+	case oldSchema.Enum == nil && newSchema.Enum != nil:
+		c.add(path, BumpMajor, "enum added: %s", strings.Join(jsonStrings(newSchema.Enum), ", "))
+	case oldSchema.Enum != nil && newSchema.Enum == nil:
+		c.add(path, BumpMinor, "enum removed")
+	case oldSchema.Enum != nil:
+		oldValues, newValues := jsonStrings(oldSchema.Enum), jsonStrings(newSchema.Enum)
+		if removed := missingFrom(oldValues, newValues); len(removed) > 0 {
+			c.add(path, BumpMajor, "enum values removed: %s", strings.Join(removed, ", "))
+		}
+		if added := missingFrom(newValues, oldValues); len(added) > 0 {
+			c.add(path, BumpMinor, "enum values added: %s", strings.Join(added, ", "))
+		}
+	}
+
+	oldConst, newConst := jsonString(oldSchema.Const), jsonString(newSchema.Const)
+	switch {
+	case oldConst == newConst:
+	case newSchema.Const == nil:
+		c.add(path, BumpMinor, "const removed")
+	default:
+		c.add(path, BumpMajor, "const changed from %s to %s", oldConst, newConst)
+	}
+}
+
+func (c *comparison) compareConstraints(oldSchema *pkg.JsonSchema, newSchema *pkg.JsonSchema, path []string) {
+	switch {
+	case oldSchema.Pattern == newSchema.Pattern:
+	case oldSchema.Pattern == "":
+		c.add(path, BumpMajor, "pattern added: %s", newSchema.Pattern)
+	case newSchema.Pattern == "":
+		c.add(path, BumpMinor, "pattern removed")
+	default:
+		c.add(path, BumpMajor, "pattern changed from %s to %s", oldSchema.Pattern, newSchema.Pattern)
+	}
+
+	c.compareLimit(path, "minimum", numberLimit(oldSchema.Minimum), numberLimit(newSchema.Minimum), true)
+	c.compareLimit(path, "maximum", numberLimit(oldSchema.Maximum), numberLimit(newSchema.Maximum), false)
+	c.compareExclusiveLimit(path, "exclusiveMinimum", oldSchema.ExclusiveMinimum, newSchema.ExclusiveMinimum, true)
+	c.compareExclusiveLimit(path, "exclusiveMaximum", oldSchema.ExclusiveMaximum, newSchema.ExclusiveMaximum, false)
+	c.compareLimit(path, "minLength", intLimit(oldSchema.MinLength), intLimit(newSchema.MinLength), true)
+	c.compareLimit(path, "maxLength", intLimit(oldSchema.MaxLength), intLimit(newSchema.MaxLength), false)
+	c.compareLimit(path, "minItems", intLimit(oldSchema.MinItems), intLimit(newSchema.MinItems), true)
+	c.compareLimit(path, "maxItems", intLimit(oldSchema.MaxItems), intLimit(newSchema.MaxItems), false)
+	c.compareLimit(path, "minProperties", intLimit(oldSchema.MinProperties), intLimit(newSchema.MinProperties), true)
+	c.compareLimit(path, "maxProperties", intLimit(oldSchema.MaxProperties), intLimit(newSchema.MaxProperties), false)
+
+	oldClosed, newClosed := closed(oldSchema), closed(newSchema)
+	switch {
+	case !oldClosed && newClosed:
+		c.add(path, BumpMajor, "undeclared keys are no longer allowed")
+	case oldClosed && !newClosed:
+		c.add(path, BumpMinor, "undeclared keys are allowed")
+	}
+
+	c.compareOtherKeywords(oldSchema, newSchema, path)
+}
+
+// compareExclusiveLimit compares an exclusiveMinimum or exclusiveMaximum
+// keyword, which is either a limit of its own or a draft-04 boolean making the
+// minimum or maximum exclusive.
+func (c *comparison) compareExclusiveLimit(path []string, keyword string, oldLimit pkg.ExclusiveLimit, newLimit pkg.ExclusiveLimit, lower bool) {
+	oldExclusive, oldIsBool := oldLimit.Bool()
+	newExclusive, newIsBool := newLimit.Bool()
+
+	switch {
+	case oldLimit == newLimit:
+	case (oldIsBool || oldLimit == "") && (newIsBool || newLimit == ""):
+		switch {
+		case !oldExclusive && newExclusive:
+			c.add(path, BumpMajor, "%s added", keyword)
+		case oldExclusive && !newExclusive:
+			c.add(path, BumpMinor, "%s removed", keyword)
+		}
+	case !oldIsBool && !newIsBool:
+		oldNumber, _ := oldLimit.Number()
+		newNumber, _ := newLimit.Number()
+		c.compareLimit(path, keyword, numberLimit(oldNumber), numberLimit(newNumber), lower)
+	default:
+		c.add(path, BumpMajor, "%s changed from %s to %s", keyword, oldLimit, newLimit)
+	}
+}
+
+// compareOtherKeywords reports changes to the validation keywords which aren't
+// compared in detail. It isn't known whether values which were valid before
+// still are, so any change is breaking.
+func (c *comparison) compareOtherKeywords(oldSchema *pkg.JsonSchema, newSchema *pkg.JsonSchema, path []string) {
+	keywords := []struct {
+		name string
+		old  any
+		new  any
+	}{
+		{"multipleOf", oldSchema.MultipleOf, newSchema.MultipleOf},
+		{"format", oldSchema.Format, newSchema.Format},
+		{"uniqueItems", oldSchema.UniqueItems, newSchema.UniqueItems},
+		{"allOf", oldSchema.AllOf, newSchema.AllOf},
+		{"anyOf", oldSchema.AnyOf, newSchema.AnyOf},
+		{"oneOf", oldSchema.OneOf, newSchema.OneOf},
+		{"not", oldSchema.Not, newSchema.Not},
+		{"if", oldSchema.If, newSchema.If},
+		{"then", oldSchema.Then, newSchema.Then},
+		{"else", oldSchema.Else, newSchema.Else},
+		{"additionalProperties", schemaValued(oldSchema.AdditionalProperties), schemaValued(newSchema.AdditionalProperties)},
+		{"patternProperties", oldSchema.PatternProperties, newSchema.PatternProperties},
+		{"propertyNames", oldSchema.PropertyNames, newSchema.PropertyNames},
+		{"dependencies", oldSchema.Dependencies, newSchema.Dependencies},
+		{"dependentRequired", oldSchema.DependentRequired, newSchema.DependentRequired},
+		{"dependentSchemas", oldSchema.DependentSchemas, newSchema.DependentSchemas},
+		{"items", tupleItems(oldSchema.Items), tupleItems(newSchema.Items)},
+		{"additionalItems", oldSchema.AdditionalItems, newSchema.AdditionalItems},
+		{"prefixItems", oldSchema.PrefixItems, newSchema.PrefixItems},
+		{"contains", oldSchema.Contains, newSchema.Contains},
+		{"minContains", oldSchema.MinContains, newSchema.MinContains},
+		{"maxContains", oldSchema.MaxContains, newSchema.MaxContains},
+	}
+
+	for _, keyword := range keywords {
+		if jsonString(keyword.old) != jsonString(keyword.new) {
+			c.add(path, BumpMajor, "%s changed", keyword.name)
+		}
+	}
+}
+
+// compareLimit compares a lower or upper limit keyword, where raising a lower
+// limit or lowering an upper limit tightens it.
+func (c *comparison) compareLimit(path []string, keyword string, oldLimit *float64, newLimit *float64, lower bool) {
+	switch {
+	case oldLimit == nil && newLimit == nil:
+	case oldLimit == nil:
+		c.add(path, BumpMajor, "%s added: %v", keyword, *newLimit)
+	case newLimit == nil:
+		c.add(path, BumpMinor, "%s removed", keyword)
+	case *oldLimit == *newLimit:
+	case (*newLimit > *oldLimit) == lower:
+		c.add(path, BumpMajor, "%s tightened from %v to %v", keyword, *oldLimit, *newLimit)
+	default:
+		c.add(path, BumpMinor, "%s loosened from %v to %v", keyword, *oldLimit, *newLimit)
+	}
+}
+
+func (c *comparison) compareAnnotations(oldSchema *pkg.JsonSchema, newSchema *pkg.JsonSchema, path []string) {
+	// the defaults of objects with properties are reported by property
+	hasProperties := oldSchema.Properties != nil && newSchema.Properties != nil
+	// installs which don't set the value get the new default, changing what the
+	// chart deploys without any change to their values
+	if oldDefault, newDefault := jsonString(oldSchema.Default), jsonString(newSchema.Default); oldDefault != newDefault && !hasProperties {
+		c.add(path, BumpMajor, "default changed from %s to %s", oldDefault, newDefault)
+	}
+	if oldSchema.Description != newSchema.Description {
+		c.add(path, BumpPatch, "description changed")
+	}
+	if !oldSchema.Deprecated && newSchema.Deprecated {
+		c.add(path, BumpMinor, "deprecated")
+	}
+}
+
+func (c *comparison) compareProperties(oldSchema *pkg.JsonSchema, newSchema *pkg.JsonSchema, path []string) {
+	oldProperties, newProperties := properties(oldSchema), properties(newSchema)
+
+	for _, key := range oldProperties {
+		keyPath := append(slices.Clone(path), key)
+		if !slices.Contains(newProperties, key) {
+			c.add(keyPath, BumpMajor, "removed")
+			continue
+		}
+
+		switch oldRequired, newRequired := slices.Contains(oldSchema.Required, key), slices.Contains(newSchema.Required, key); {
+		case !oldRequired && newRequired:
+			c.add(keyPath, BumpMajor, "is now required")
+		case oldRequired && !newRequired:
+			c.add(keyPath, BumpMinor, "is no longer required")
+		}
+
+		oldProperty, _ := oldSchema.Properties.Get(key)
+		newProperty, _ := newSchema.Properties.Get(key)
+		c.compare(oldProperty, newProperty, keyPath)
+	}
+
+	for _, key := range newProperties {
+		if slices.Contains(oldProperties, key) {
+			continue
+		}
+		keyPath := append(slices.Clone(path), key)
+		if slices.Contains(newSchema.Required, key) {
+			c.add(keyPath, BumpMajor, "added as a required key")
+		} else {
+			c.add(keyPath, BumpMinor, "added")
+		}
+	}
+}
+
+func (c *comparison) add(path []string, bump Bump, format string, args ...any) {
+	c.changes = append(c.changes, Change{
+		Path:    strings.ReplaceAll(strings.Join(path, "."), ".[", "["),
+		Message: fmt.Sprintf(format, args...),
+		Bump:    bump,
+	})
+}
+
+// resolve returns the definition a local reference points at, or the schema
+// and its reference if it isn't a local reference.
+func resolve(root *pkg.JsonSchema, s *pkg.JsonSchema) (*pkg.JsonSchema, string) {
+	seen := []string{}
+	for s.Ref != "" && !slices.Contains(seen, s.Ref) {
+		definition, ok := root.ResolveLocalRef(s.Ref)
+		if !ok {
+			return s, s.Ref
+		}
+		seen = append(seen, s.Ref)
+		s = definition
+	}
+	return s, ""
+}
+
+// acceptsTypes reports whether every value of the old types is a value of the
+// new types. An empty type accepts any value.
+func acceptsTypes(newType pkg.SchemaType, oldType pkg.SchemaType) bool {
+	if len(newType) == 0 {
+		return true
+	}
+	if len(oldType) == 0 {
+		return false
+	}
+
+	for _, t := range oldType {
+		if !newType.Is(t) && !(t == "integer" && newType.Is("number")) {
+			return false
+		}
+	}
+	return true
+}
+
+func typeLabel(t pkg.SchemaType) string {
+	if len(t) == 0 {
+		return "any"
+	}
+	return t.String()
+}
+
+// closed reports whether the schema disallows keys it doesn't declare.
+func closed(s *pkg.JsonSchema) bool {
+	allowed, ok := s.AdditionalProperties.(bool)
+	return ok && !allowed
+}
+
+// schemaValued returns the additionalProperties keyword when it is a schema,
+// the boolean forms are compared by closed.
+func schemaValued(additionalProperties any) any {
+	if _, ok := additionalProperties.(bool); ok {
+		return nil
+	}
+	return additionalProperties
+}
+
+// tupleItems returns the items keyword when it is a list of schemas, a single
+// items schema is compared like a property.
+func tupleItems(items any) any {
+	if pkg.KeywordSchema(items) != nil {
+		return nil
+	}
+	return items
+}
+
+func properties(s *pkg.JsonSchema) []string {
+	if s.Properties == nil {
+		return []string{}
+	}
+	return slices.Collect(s.Properties.Keys())
+}
+
+func numberLimit(n pkg.Number) *float64 {
+	if n == "" {
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+func intLimit(n *int64) *float64 {
+	if n == nil {
+		return nil
+	}
+	f := float64(*n)
+	return &f
+}
+
+func orEmpty(s *pkg.JsonSchema) *pkg.JsonSchema {
+	if s == nil {
+		return &pkg.JsonSchema{}
+	}
+	return s
+}
+
+// missingFrom returns the values which are not in others.
+func missingFrom(values []string, others []string) []string {
+	missing := []string{}
+	for _, value := range values {
+		if !slices.Contains(others, value) {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+func jsonString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func jsonStrings(values []any) []string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = jsonString(value)
+	}
+	return strs
+}
@@ -0,0 +1,187 @@
+package compat
+
+import (
+	"helmvalues/pkg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func parseSchema(t *testing.T, content string) *pkg.JsonSchema {
+	t.Helper()
+	s := &pkg.JsonSchema{}
+	require.NoError(t, yaml.Unmarshal([]byte(content), s))
+	return s
+}
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		name    string
+		old     string
+		new     string
+		changes []string
+		bump    Bump
+	}{
+		{
+			name:    "unchanged",
+			old:     "properties: {name: {type: string, default: foo}}",
+			new:     "properties: {name: {type: string, default: foo}}",
+			changes: []string{},
+			bump:    BumpNone,
+		},
+		{
+			name: "removed and added keys",
+			old:  "properties: {name: {type: string}, legacy: {type: boolean}}",
+			new:  "properties: {name: {type: string}, extra: {type: object}, port: {type: integer}}\nrequired: [port]",
+			changes: []string{
+				"breaking: legacy: removed",
+				"non-breaking: extra: added",
+				"breaking: port: added as a required key",
+			},
+			bump: BumpMajor,
+		},
+		{
+			name: "types",
+			old:  "properties: {a: {type: [integer, string]}, b: {type: integer}, c: {}}",
+			new:  "properties: {a: {type: integer}, b: {type: number}, c: {type: string}}",
+			changes: []string{
+				"breaking: a: type narrowed from integer, string to integer",
+				"non-breaking: b: type widened from integer to number",
+				"breaking: c: type narrowed from any to string",
+			},
+			bump: BumpMajor,
+		},
+		{
+			name: "enums and patterns",
+			old:  "properties: {level: {enum: [debug, info]}, name: {type: string}}",
+			new:  "properties: {level: {enum: [info, warn]}, name: {type: string, pattern: '^[a-z]+$'}}",
+			changes: []string{
+				`breaking: level: enum values removed: "debug"`,
+				`non-breaking: level: enum values added: "warn"`,
+				"breaking: name: pattern added: ^[a-z]+$",
+			},
+			bump: BumpMajor,
+		},
+		{
+			name: "limits",
+			old:  "properties: {replicas: {type: integer, minimum: 1, maximum: 10}}",
+			new:  "properties: {replicas: {type: integer, minimum: 0, maximum: 5}}",
+			changes: []string{
+				"non-breaking: replicas: minimum loosened from 1 to 0",
+				"breaking: replicas: maximum tightened from 10 to 5",
+			},
+			bump: BumpMajor,
+		},
+		{
+			name: "required and additional properties",
+			old:  "properties: {image: {type: object, additionalProperties: false, properties: {tag: {type: string}}}}",
+			new:  "properties: {image: {type: object, required: [tag], properties: {tag: {type: string}}}}",
+			changes: []string{
+				"non-breaking: image: undeclared keys are allowed",
+				"breaking: image.tag: is now required",
+			},
+			bump: BumpMajor,
+		},
+		{
+			name:    "defaults",
+			old:     "properties: {replicas: {type: integer, default: 1}}",
+			new:     "properties: {replicas: {type: integer, default: 2}}",
+			changes: []string{"breaking: replicas: default changed from 1 to 2"},
+			bump:    BumpMajor,
+		},
+		{
+			name: "exclusive limits",
+			old:  "properties: {cpu: {type: number, exclusiveMinimum: 0}, memory: {type: integer, minimum: 1, exclusiveMinimum: true}, disk: {type: integer, maximum: 10}}",
+			new:  "properties: {cpu: {type: number, exclusiveMinimum: 1}, memory: {type: integer, minimum: 1}, disk: {type: integer, maximum: 10, exclusiveMaximum: true}}",
+			changes: []string{
+				"breaking: cpu: exclusiveMinimum tightened from 0 to 1",
+				"non-breaking: memory: exclusiveMinimum removed",
+				"breaking: disk: exclusiveMaximum added",
+			},
+			bump: BumpMajor,
+		},
+		{
+			name: "keywords without a detailed comparison",
+			old:  "properties: {port: {anyOf: [{type: integer}, {type: string}]}, labels: {type: object, additionalProperties: {type: string}}}",
+			new:  "properties: {port: {oneOf: [{type: integer}]}, labels: {type: object, additionalProperties: {type: integer}}}",
+			changes: []string{
+				"breaking: port: anyOf changed",
+				"breaking: port: oneOf changed",
+				"breaking: labels: additionalProperties changed",
+			},
+			bump: BumpMajor,
+		},
+		{
+			name:    "descriptions",
+			old:     "properties: {replicas: {type: integer, description: pods}}",
+			new:     "properties: {replicas: {type: integer, description: number of pods}}",
+			changes: []string{"non-breaking: replicas: description changed"},
+			bump:    BumpPatch,
+		},
+		{
+			name:    "items and references",
+			old:     "properties: {ports: {type: array, items: {$ref: '#/definitions/port'}}}\ndefinitions: {port: {type: integer}}",
+			new:     "properties: {ports: {type: array, items: {type: integer, maximum: 65535}}}",
+			changes: []string{"breaking: ports[]: maximum added: 65535"},
+			bump:    BumpMajor,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(tt *testing.T) {
+			changes := Compare(parseSchema(tt, tc.old), parseSchema(tt, tc.new))
+
+			lines := []string{}
+			for _, change := range changes {
+				lines = append(lines, change.String())
+			}
+			assert.Equal(tt, tc.changes, lines)
+			assert.Equal(tt, tc.bump, RequiredBump(changes))
+		})
+	}
+}
+
+func TestSuggestVersion(t *testing.T) {
+	for bump, expected := range map[Bump]string{
+		BumpNone:  "1.2.3",
+		BumpPatch: "1.2.4",
+		BumpMinor: "1.3.0",
+		BumpMajor: "2.0.0",
+	} {
+		suggested, err := SuggestVersion("1.2.3", bump)
+		require.NoError(t, err)
+		assert.Equal(t, expected, suggested, bump.String())
+	}
+
+	covered, err := CoversVersion("1.3.0", "2.0.0")
+	require.NoError(t, err)
+	assert.False(t, covered)
+
+	_, err = SuggestVersion("latest", BumpMajor)
+	assert.Error(t, err)
+}
+
+func TestVersionBump(t *testing.T) {
+	testCases := []struct {
+		version  string
+		bump     Bump
+		expected Bump
+	}{
+		{version: "1.2.3", bump: BumpMajor, expected: BumpMajor},
+		{version: "0.3.1", bump: BumpMajor, expected: BumpMinor},
+		{version: "0.3.1", bump: BumpMinor, expected: BumpMinor},
+		{version: "0.3.1", bump: BumpPatch, expected: BumpPatch},
+	}
+
+	for _, tc := range testCases {
+		bump, err := VersionBump(tc.version, tc.bump)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, bump, "%s %s", tc.version, tc.bump)
+	}
+
+	suggested, err := SuggestVersion("0.3.1", BumpMinor)
+	require.NoError(t, err)
+	assert.Equal(t, "0.4.0", suggested)
+}
@@ -0,0 +1,92 @@
+package compat
+
+import (
+	"errors"
+	"fmt"
+	"helmvalues/internal/charts"
+	"helmvalues/pkg/schema"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrVersionNotBumped is returned by CompareCharts when the new chart's
+// version is lower than the version its changes require.
+var ErrVersionNotBumped = errors.New("chart version is not bumped enough for its changes")
+
+// CompareCharts prints the changes between the values schemas of the old and
+// new chart, and the chart version the changes require.
+func CompareCharts(logger *logrus.Logger, cfg *Config, oldChartDir string, newChartDir string) error {
+	oldChart, err := charts.NewChart(oldChartDir)
+	if err != nil {
+		return err
+	}
+	newChart, err := charts.NewChart(newChartDir)
+	if err != nil {
+		return err
+	}
+	name := newChart.Details.Name
+
+	logger.Infof("compat: %s: comparing %s to %s", name, oldChart.RootPath(), newChart.RootPath())
+	schemaCfg := &schema.Config{
+		DryRun:               true,
+		TagSchemas:           cfg.TagSchemas,
+		ComposeSubcharts:     cfg.ComposeSubcharts,
+		AdditionalProperties: cfg.AdditionalProperties,
+		CommentDialect:       cfg.CommentDialect,
+		FootComments:         cfg.FootComments,
+		DetachedMarker:       cfg.DetachedMarker,
+		LogLevel:             cfg.LogLevel,
+	}
+	oldSchema, err := schema.LoadSchema(logger, cfg.Schema, schemaCfg, oldChart)
+	if err != nil {
+		return err
+	}
+	newSchema, err := schema.LoadSchema(logger, cfg.Schema, schemaCfg, newChart)
+	if err != nil {
+		return err
+	}
+
+	changes := Compare(oldSchema, newSchema)
+	breaking := 0
+	for _, change := range changes {
+		fmt.Println(change)
+		if change.Breaking() {
+			breaking++
+		}
+	}
+	fmt.Printf("%s: %d breaking, %d non-breaking changes\n", name, breaking, len(changes)-breaking)
+
+	bump := RequiredBump(changes)
+	if bump == BumpNone {
+		fmt.Printf("%s: no version bump required\n", name)
+		return nil
+	}
+
+	versionBump, err := VersionBump(oldChart.Details.Version, bump)
+	if err != nil {
+		return err
+	}
+	suggested, err := SuggestVersion(oldChart.Details.Version, versionBump)
+	if err != nil {
+		return err
+	}
+	if versionBump != bump {
+		fmt.Printf(
+			"%s: %s bump required for breaking changes before 1.0.0, suggested version %s (from %s)\n",
+			name, versionBump, suggested, oldChart.Details.Version,
+		)
+	} else {
+		fmt.Printf("%s: %s bump required, suggested version %s (from %s)\n", name, bump, suggested, oldChart.Details.Version)
+	}
+
+	covered, err := CoversVersion(newChart.Details.Version, suggested)
+	if err != nil {
+		return err
+	}
+	if !covered {
+		return fmt.Errorf("%w: %s is lower than %s", ErrVersionNotBumped, newChart.Details.Version, suggested)
+	}
+
+	logger.Infof("compat: %s: version %s covers the changes", name, newChart.Details.Version)
+	return nil
+}
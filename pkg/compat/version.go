@@ -0,0 +1,54 @@
+package compat
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// VersionBump returns the bump the version takes for the required bump.
+// Versions below 1.0.0 are in initial development, where breaking changes bump
+// the minor version rather than the major version.
+func VersionBump(version string, bump Bump) (Bump, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return BumpNone, fmt.Errorf("invalid chart version %q: %w", version, err)
+	}
+
+	if bump == BumpMajor && v.Major() == 0 {
+		return BumpMinor, nil
+	}
+	return bump, nil
+}
+
+// SuggestVersion returns the chart version after the bump.
+func SuggestVersion(version string, bump Bump) (string, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart version %q: %w", version, err)
+	}
+
+	switch bump {
+	case BumpMajor:
+		return v.IncMajor().String(), nil
+	case BumpMinor:
+		return v.IncMinor().String(), nil
+	case BumpPatch:
+		return v.IncPatch().String(), nil
+	default:
+		return v.String(), nil
+	}
+}
+
+// CoversVersion reports whether the version is at least the suggested version.
+func CoversVersion(version string, suggested string) (bool, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false, fmt.Errorf("invalid chart version %q: %w", version, err)
+	}
+	s, err := semver.NewVersion(suggested)
+	if err != nil {
+		return false, fmt.Errorf("invalid chart version %q: %w", suggested, err)
+	}
+	return !v.LessThan(s), nil
+}
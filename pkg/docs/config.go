@@ -5,9 +5,11 @@ import (
 	"strings"
 
 	"helmvalues/pkg/docs/templates"
+	"helmvalues/pkg/schema/comments"
 
 	"github.com/samber/mo"
 	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
 )
 
 type Config struct {
@@ -15,12 +17,19 @@ type Config struct {
 	StdOut         bool
 	Strict         bool
 	DryRun         bool
+	Check          bool
+	Diff           bool
+	Color          bool
 	UseDefault     mo.Option[bool]
 	Output         mo.Option[string]
 	Template       string
 	ExtraTemplates []string
 	Markup         mo.Option[templates.Markup]
 	Order          ValuesOrder
+	TagSchemas     map[string]*yaml.Node
+	CommentDialect comments.Dialect
+	FootComments   bool
+	DetachedMarker string
 }
 
 type ValuesOrder string
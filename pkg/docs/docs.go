@@ -9,6 +9,7 @@ import (
 	"helmvalues/pkg"
 	"helmvalues/pkg/docs/templates"
 	"helmvalues/pkg/schema"
+	"maps"
 	"os"
 	"slices"
 	"sort"
@@ -45,6 +46,7 @@ func GenerateDocs(logger *logrus.Logger, cfg *Config, chartDirs []string) error
 	}
 
 	// Iterate through plans again, this time generating the docs
+	outdated, failed := 0, 0
 	for _, plan := range plans {
 		logger.Infof("docs: %s: starting generation", plan.Chart().Details.Name)
 
@@ -52,16 +54,22 @@ func GenerateDocs(logger *logrus.Logger, cfg *Config, chartDirs []string) error
 		jsonschema, err := schema.NewGenerator(logger, plan.SchemaPlan()).Generate()
 		if err != nil {
 			logger.Error(err.Error())
+			if plan.Check() {
+				failed++
+				continue
+			}
 			return nil
 		}
 		logger.Tracef("docs: %s: jsonschema properties: %+v", plan.Chart().Details.Name, jsonschema.Properties)
 
+		rows := schemaProperties(jsonschema, jsonschema, cfg.Order, []string{}, []string{})
 		table := templates.TemplateContext{
 			Raw: &templates.RawContext{
 				Chart:  plan.Chart(),
 				Values: jsonschema,
 			},
-			ValuesTable: schemaProperties(jsonschema, cfg.Order, []string{}),
+			ValuesTable:    rows,
+			ValuesSections: valuesSections(rows),
 		}
 
 		for _, p := range staticPaths {
@@ -119,6 +127,32 @@ func GenerateDocs(logger *logrus.Logger, cfg *Config, chartDirs []string) error
 			return err
 		}
 
+		if plan.Diff() {
+			logger.Debugf("docs: %s: printing diff", plan.Chart().Details.Name)
+			changes, err := plan.ReadmeChanges(buf.String())
+			if err != nil {
+				return err
+			}
+			if err := internal.WriteDiffs(os.Stdout, plan.Chart().Details.Name, changes, plan.Color()); err != nil {
+				return err
+			}
+		}
+
+		if plan.Check() {
+			logger.Debugf("docs: %s: checking output", plan.Chart().Details.Name)
+			paths, err := plan.CheckReadme(buf.String())
+			if err != nil {
+				return err
+			}
+			for _, path := range paths {
+				fmt.Printf("%s: %s is out of date\n", plan.Chart().Details.Name, path)
+			}
+			outdated += len(paths)
+
+			logger.Infof("docs: %s: finished", plan.Chart().Details.Name)
+			continue
+		}
+
 		logger.Debugf("docs: %s: writing output", plan.Chart().Details.Name)
 		if err := plan.WriteReadme(logger, buf.String()); err != nil {
 			return err
@@ -127,11 +161,17 @@ func GenerateDocs(logger *logrus.Logger, cfg *Config, chartDirs []string) error
 		logger.Infof("docs: %s: finished", plan.Chart().Details.Name)
 	}
 
-	return nil
+	return schema.CheckResult(outdated, failed)
 }
 
-func schemaProperties(jsonschema *pkg.JsonSchema, order ValuesOrder, parents []string) []templates.ValuesRow {
+// schemaProperties builds the values rows for the properties of the schema.
+// References to definitions in the root schema are expanded in place, with refs
+// tracking the definitions being expanded to guard against recursion.
+func schemaProperties(root *pkg.JsonSchema, jsonschema *pkg.JsonSchema, order ValuesOrder, parents []string, refs []string) []templates.ValuesRow {
 	rows := []templates.ValuesRow{}
+	if jsonschema.Properties == nil {
+		return rows
+	}
 
 	// Key order is preserved by default
 	keys := slices.Collect(jsonschema.Properties.Keys())
@@ -148,6 +188,20 @@ func schemaProperties(jsonschema *pkg.JsonSchema, order ValuesOrder, parents []s
 			continue
 		}
 
+		if prop.DocsIgnored {
+			continue
+		}
+
+		propRefs := refs
+		for prop.Ref != "" && !slices.Contains(propRefs, prop.Ref) {
+			definition, ok := root.ResolveLocalRef(prop.Ref)
+			if !ok {
+				break
+			}
+			propRefs = append(slices.Clone(propRefs), prop.Ref)
+			prop = expandDefinition(prop, definition)
+		}
+
 		if prop.Ref != "" {
 			row := templates.ValuesRow{
 				Key:  strings.Join(append(parents, key), "."),
@@ -166,8 +220,14 @@ func schemaProperties(jsonschema *pkg.JsonSchema, order ValuesOrder, parents []s
 			continue
 		}
 
-		if prop.Type == "object" {
-			rows = append(rows, schemaProperties(prop, order, append(parents, key))...)
+		if prop.Type.Is("object") {
+			// values within an object inherit its docs section
+			for _, row := range schemaProperties(root, prop, order, append(parents, key), propRefs) {
+				if row.Section == "" {
+					row.Section = prop.DocsSection
+				}
+				rows = append(rows, row)
+			}
 			continue
 		}
 
@@ -177,7 +237,7 @@ func schemaProperties(jsonschema *pkg.JsonSchema, order ValuesOrder, parents []s
 			fmt.Printf("Error marshaling default value for key %s: %v\n", key, err)
 		}
 
-		typeValue := prop.Type
+		typeValue := typeLabel(prop)
 		if len(prop.Enum) > 0 {
 			enumItems := make([]string, len(prop.Enum))
 			for i, enumItem := range prop.Enum {
@@ -196,14 +256,124 @@ func schemaProperties(jsonschema *pkg.JsonSchema, order ValuesOrder, parents []s
 			)
 		}
 
+		if prop.DocsDefault != "" {
+			defaultStr = []byte(prop.DocsDefault)
+		}
+
 		row := templates.ValuesRow{
 			Key:         strings.Join(append(parents, key), "."),
 			Type:        typeValue,
 			Default:     string(defaultStr),
 			Description: prop.Description,
+			Required:    slices.Contains(jsonschema.Required, key),
+			Section:     prop.DocsSection,
+			Extensions:  extensions(prop),
 		}
 		rows = append(rows, row)
 	}
 
 	return rows
 }
+
+// expandDefinition returns the definition with the annotations of the property
+// referencing it applied on top. Definitions are shared, so the default of the
+// property also sets the defaults of the definition's properties.
+func expandDefinition(prop *pkg.JsonSchema, definition *pkg.JsonSchema) *pkg.JsonSchema {
+	expanded := *definition
+	expanded.Title = prop.Title
+	if prop.Description != "" {
+		expanded.Description = prop.Description
+	}
+	if prop.Default != nil {
+		expanded = *withDefault(&expanded, prop.Default)
+	}
+	if prop.Extensions != nil {
+		expanded.Extensions = pkg.NewEncodableOrderedMap[string, any]()
+		for _, source := range []*pkg.JsonSchema{definition, prop} {
+			if source.Extensions == nil {
+				continue
+			}
+			for key, value := range source.Extensions.AllFromFront() {
+				expanded.Extensions.Set(key, value)
+			}
+		}
+	}
+	return &expanded
+}
+
+// withDefault returns a copy of the schema with the default, whose properties
+// are given the values of the default's keys as their defaults.
+func withDefault(s *pkg.JsonSchema, value any) *pkg.JsonSchema {
+	c := *s
+	c.Default = value
+
+	values, ok := value.(map[string]any)
+	if !ok || s.Properties == nil {
+		return &c
+	}
+	c.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+	for key, prop := range s.Properties.AllFromFront() {
+		if propValue, ok := values[key]; ok {
+			prop = withDefault(prop, propValue)
+		}
+		c.Properties.Set(key, prop)
+	}
+	return &c
+}
+
+// valuesSections groups the rows by docs section, in the order the sections
+// first appear, followed by the rows without a section. It returns nil when
+// no rows have a section.
+func valuesSections(rows []templates.ValuesRow) []templates.ValuesSection {
+	sections := []templates.ValuesSection{}
+	unsectioned := []templates.ValuesRow{}
+	for _, row := range rows {
+		if row.Section == "" {
+			unsectioned = append(unsectioned, row)
+			continue
+		}
+
+		idx := slices.IndexFunc(sections, func(s templates.ValuesSection) bool { return s.Name == row.Section })
+		if idx == -1 {
+			sections = append(sections, templates.ValuesSection{Name: row.Section})
+			idx = len(sections) - 1
+		}
+		sections[idx].ValuesTable = append(sections[idx].ValuesTable, row)
+	}
+
+	if len(sections) == 0 {
+		return nil
+	}
+	if len(unsectioned) > 0 {
+		sections = append(sections, templates.ValuesSection{ValuesTable: unsectioned})
+	}
+	return sections
+}
+
+// extensions returns the extension keywords of the schema, or nil if it has none.
+func extensions(s *pkg.JsonSchema) map[string]any {
+	if s.Extensions == nil || s.Extensions.Len() == 0 {
+		return nil
+	}
+	return maps.Collect(s.Extensions.AllFromFront())
+}
+
+// typeLabel describes the type of the property, including any keywords that
+// further constrain the format of the value.
+func typeLabel(prop *pkg.JsonSchema) string {
+	qualifiers := []string{}
+	if prop.Format != "" {
+		qualifiers = append(qualifiers, prop.Format)
+	}
+	if prop.ContentEncoding != "" {
+		qualifiers = append(qualifiers, prop.ContentEncoding)
+	}
+	if prop.ContentMediaType != "" {
+		qualifiers = append(qualifiers, prop.ContentMediaType)
+	}
+
+	if len(qualifiers) == 0 {
+		return prop.Type.String()
+	}
+	return fmt.Sprintf("%s (%s)", prop.Type, strings.Join(qualifiers, ", "))
+}
@@ -0,0 +1,150 @@
+package docs
+
+import (
+	"helmvalues/pkg"
+	"helmvalues/pkg/docs/templates"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaPropertiesExpandsLocalRefs(t *testing.T) {
+	probe := &pkg.JsonSchema{
+		Type:       pkg.NewSchemaType("object"),
+		Properties: properties("periodSeconds", &pkg.JsonSchema{Type: pkg.NewSchemaType("integer"), Description: "probe period"}),
+	}
+	recursive := &pkg.JsonSchema{
+		Type:       pkg.NewSchemaType("object"),
+		Properties: properties("child", &pkg.JsonSchema{Ref: "#/definitions/recursive"}),
+	}
+
+	root := &pkg.JsonSchema{
+		Definitions: properties("probe", probe, "recursive", recursive),
+		Properties: properties(
+			"livenessProbe", &pkg.JsonSchema{Ref: "#/definitions/probe", Description: "liveness probe"},
+			"tree", &pkg.JsonSchema{Ref: "#/definitions/recursive"},
+			"resources", &pkg.JsonSchema{Ref: "https://example.com/resources.json"},
+		),
+	}
+
+	rows := schemaProperties(root, root, ValuesOrderPreserve, []string{}, []string{})
+	assert.Equal(t, []templates.ValuesRow{
+		{Key: "livenessProbe.periodSeconds", Type: "integer", Default: "null", Description: "probe period"},
+		{Key: "tree.child", Type: "[Ref](#/definitions/recursive)"},
+		{Key: "resources", Type: "[Ref](https://example.com/resources.json)"},
+	}, rows)
+}
+
+func TestSchemaPropertiesRefDefaults(t *testing.T) {
+	probe := &pkg.JsonSchema{
+		Type: pkg.NewSchemaType("object"),
+		Properties: properties(
+			"periodSeconds", &pkg.JsonSchema{Type: pkg.NewSchemaType("integer")},
+			"httpGet", &pkg.JsonSchema{
+				Type:       pkg.NewSchemaType("object"),
+				Properties: properties("path", &pkg.JsonSchema{Type: pkg.NewSchemaType("string")}),
+			},
+		),
+	}
+
+	root := &pkg.JsonSchema{
+		Definitions: properties("probe", probe),
+		Properties: properties(
+			"livenessProbe", &pkg.JsonSchema{
+				Ref:     "#/definitions/probe",
+				Default: map[string]any{"periodSeconds": 10, "httpGet": map[string]any{"path": "/healthz"}},
+			},
+			"readinessProbe", &pkg.JsonSchema{
+				Ref:     "#/definitions/probe",
+				Default: map[string]any{"periodSeconds": 5},
+			},
+		),
+	}
+
+	rows := schemaProperties(root, root, ValuesOrderPreserve, []string{}, []string{})
+	assert.Equal(t, []templates.ValuesRow{
+		{Key: "livenessProbe.periodSeconds", Type: "integer", Default: "10"},
+		{Key: "livenessProbe.httpGet.path", Type: "string", Default: `"/healthz"`},
+		{Key: "readinessProbe.periodSeconds", Type: "integer", Default: "5"},
+		{Key: "readinessProbe.httpGet.path", Type: "string", Default: "null"},
+	}, rows)
+
+	// the shared definition is left unchanged
+	periodSeconds, _ := probe.Properties.Get("periodSeconds")
+	assert.Nil(t, periodSeconds.Default)
+}
+
+func TestSchemaPropertiesExtensions(t *testing.T) {
+	secret := &pkg.JsonSchema{Type: pkg.NewSchemaType("string"), Extensions: extensionKeywords("x-sensitive", true, "x-group", "auth")}
+
+	root := &pkg.JsonSchema{
+		Definitions: properties("secret", secret),
+		Properties: properties(
+			"password", &pkg.JsonSchema{Ref: "#/definitions/secret", Extensions: extensionKeywords("x-group", "database")},
+			"username", &pkg.JsonSchema{Type: pkg.NewSchemaType("string")},
+		),
+	}
+
+	rows := schemaProperties(root, root, ValuesOrderPreserve, []string{}, []string{})
+	assert.Equal(t, map[string]any{"x-sensitive": true, "x-group": "database"}, rows[0].Extensions)
+	assert.Nil(t, rows[1].Extensions)
+}
+
+func TestSchemaPropertiesRequired(t *testing.T) {
+	root := &pkg.JsonSchema{
+		Required: []string{"host"},
+		Properties: properties(
+			"host", &pkg.JsonSchema{Type: pkg.NewSchemaType("string")},
+			"port", &pkg.JsonSchema{Type: pkg.NewSchemaType("integer")},
+		),
+	}
+
+	rows := schemaProperties(root, root, ValuesOrderPreserve, []string{}, []string{})
+	assert.True(t, rows[0].Required)
+	assert.False(t, rows[1].Required)
+}
+
+func TestSchemaPropertiesHelmDocsAnnotations(t *testing.T) {
+	root := &pkg.JsonSchema{
+		Properties: properties(
+			"image", &pkg.JsonSchema{Type: pkg.NewSchemaType("string"), Default: "nginx"},
+			"autoscaling", &pkg.JsonSchema{
+				Type:        pkg.NewSchemaType("object"),
+				DocsSection: "Scaling",
+				Properties: properties(
+					"replicas", &pkg.JsonSchema{Type: pkg.NewSchemaType("integer"), DocsDefault: "one per node"},
+					"internal", &pkg.JsonSchema{Type: pkg.NewSchemaType("boolean"), DocsIgnored: true},
+				),
+			},
+		),
+	}
+
+	rows := schemaProperties(root, root, ValuesOrderPreserve, []string{}, []string{})
+	assert.Equal(t, []templates.ValuesRow{
+		{Key: "image", Type: "string", Default: `"nginx"`},
+		{Key: "autoscaling.replicas", Type: "integer", Default: "one per node", Section: "Scaling"},
+	}, rows)
+
+	sections := valuesSections(rows)
+	assert.Equal(t, []templates.ValuesSection{
+		{Name: "Scaling", ValuesTable: rows[1:]},
+		{ValuesTable: rows[:1]},
+	}, sections)
+	assert.Nil(t, valuesSections(rows[:1]))
+}
+
+func extensionKeywords(pairs ...any) *pkg.EncodableOrderedMap[string, any] {
+	m := pkg.NewEncodableOrderedMap[string, any]()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m.Set(pairs[i].(string), pairs[i+1])
+	}
+	return m
+}
+
+func properties(pairs ...any) *pkg.EncodableOrderedMap[string, *pkg.JsonSchema] {
+	m := pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		m.Set(pairs[i].(string), pairs[i+1].(*pkg.JsonSchema))
+	}
+	return m
+}
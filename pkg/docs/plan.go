@@ -3,6 +3,7 @@ package docs
 import (
 	"errors"
 	"fmt"
+	"helmvalues/internal"
 	"helmvalues/internal/charts"
 	"helmvalues/pkg/docs/templates"
 	"helmvalues/pkg/schema"
@@ -13,11 +14,15 @@ import (
 
 func NewPlan(cfg *Config, chart *charts.Chart) *Plan {
 	schemaCfg := &schema.Config{
-		StdOut:        cfg.StdOut,
-		Strict:        cfg.Strict,
-		DryRun:        cfg.DryRun,
-		WriteModeline: false,
-		LogLevel:      cfg.LogLevel,
+		StdOut:         cfg.StdOut,
+		Strict:         cfg.Strict,
+		DryRun:         cfg.DryRun,
+		WriteModeline:  false,
+		TagSchemas:     cfg.TagSchemas,
+		CommentDialect: cfg.CommentDialect,
+		FootComments:   cfg.FootComments,
+		DetachedMarker: cfg.DetachedMarker,
+		LogLevel:       cfg.LogLevel,
 	}
 	schemaPlan := schema.NewPlan(schemaCfg, chart)
 
@@ -37,6 +42,9 @@ type Plan struct {
 func (p *Plan) LogCommonDetails(logger *logrus.Logger) {
 	// common configs
 	logger.Debugf("plan: %s: DryRun=%t", p.chart.Details.Name, p.DryRun())
+	logger.Debugf("plan: %s: Check=%t", p.chart.Details.Name, p.Check())
+	logger.Debugf("plan: %s: Diff=%t", p.chart.Details.Name, p.Diff())
+	logger.Debugf("plan: %s: Color=%t", p.chart.Details.Name, p.Color())
 	logger.Debugf("plan: %s: StrictComments=%t", p.chart.Details.Name, p.StrictComments())
 	logger.Debugf("plan: %s: Stdout=%t", p.chart.Details.Name, p.StdOut())
 }
@@ -81,6 +89,18 @@ func (p *Plan) DryRun() bool {
 	return p.cfg.DryRun
 }
 
+func (p *Plan) Check() bool {
+	return p.cfg.Check
+}
+
+func (p *Plan) Diff() bool {
+	return p.cfg.Diff
+}
+
+func (p *Plan) Color() bool {
+	return p.cfg.Color
+}
+
 func (p *Plan) DocsTargetTemplate() (string, bool, error) {
 	if p.cfg.Template != "" {
 		return p.cfg.Template, false, nil
@@ -196,3 +216,35 @@ func (p *Plan) WriteReadme(logger *logrus.Logger, s string) error {
 
 	return nil
 }
+
+// ReadmeChanges returns the file the docs command writes, with its content on
+// disk and the content it would be written with.
+func (p *Plan) ReadmeChanges(s string) ([]internal.FileChange, error) {
+	outputPath, err := p.DocsOutputPath()
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := internal.NewFileChange(outputPath, s)
+	if err != nil {
+		return nil, err
+	}
+	return []internal.FileChange{change}, nil
+}
+
+// CheckReadme returns the files of the chart which differ from what the docs
+// command would write, without writing them.
+func (p *Plan) CheckReadme(s string) ([]string, error) {
+	changes, err := p.ReadmeChanges(s)
+	if err != nil {
+		return nil, err
+	}
+
+	outdated := []string{}
+	for _, change := range changes {
+		if change.Changed() {
+			outdated = append(outdated, change.Path)
+		}
+	}
+	return outdated, nil
+}
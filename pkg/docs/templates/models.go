@@ -10,6 +10,19 @@ type ValuesRow struct {
 	Type        string
 	Default     string
 	Description string
+	// Section is the docs section the value is listed under, if any
+	Section string
+	// Required is set when the value is listed in its parent's required keyword
+	Required bool
+	// Extensions holds the vendor extension keywords (x-*) of the value
+	Extensions map[string]any
+}
+
+// ValuesSection holds the rows of a docs section. The rows without a section
+// are grouped in a section without a name.
+type ValuesSection struct {
+	Name        string
+	ValuesTable []ValuesRow
 }
 
 type RawContext struct {
@@ -20,4 +33,7 @@ type RawContext struct {
 type TemplateContext struct {
 	Raw         *RawContext
 	ValuesTable []ValuesRow
+	// ValuesSections holds the rows grouped by section, and is empty when no
+	// values have a section
+	ValuesSections []ValuesSection
 }
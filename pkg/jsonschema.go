@@ -1,82 +1,316 @@
 package pkg
 
 import (
-	"regexp"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
 )
 
 type JsonSchema struct {
-	Location string `json:"location,omitempty" yaml:"location,omitempty"`
-
 	// Draft *Draft `json:"draft,omitempty"`
-	Schema string `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Schema     string          `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	ID         string          `json:"$id,omitempty" yaml:"$id,omitempty"`
+	Anchor     string          `json:"$anchor,omitempty" yaml:"$anchor,omitempty"`
+	Vocabulary map[string]bool `json:"$vocabulary,omitempty" yaml:"$vocabulary,omitempty"`
 
 	Format string `json:"format,omitempty" yaml:"format,omitempty"`
 
-	Always          *bool  `json:"always,omitempty" yaml:"always,omitempty"`
+	Definitions *EncodableOrderedMap[string, *JsonSchema] `json:"definitions,omitempty" yaml:"definitions,omitempty"`
+	Defs        *EncodableOrderedMap[string, *JsonSchema] `json:"$defs,omitempty" yaml:"$defs,omitempty"`
+	Define      string                                    `json:"-" yaml:"define,omitempty"`
+
+	AdditionalPropertiesPolicy string `json:"-" yaml:"additionalPropertiesPolicy,omitempty"`
+	SkipProperties             bool   `json:"-" yaml:"skipProperties,omitempty"`
+
 	Ref             string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
-	RecursiveAnchor bool   `json:"recursiveAnchor,omitempty" yaml:"recursiveAnchor,omitempty"`
-	RecursiveRef    string `json:"recursiveRef,omitempty" yaml:"recursiveRef,omitempty"`
-	DynamicAnchor   string `json:"dynamicAnchor,omitempty" yaml:"dynamicAnchor,omitempty"`
-	DynamicRef      string `json:"dynamicRef,omitempty" yaml:"dynamicRef,omitempty"`
+	RecursiveAnchor bool   `json:"$recursiveAnchor,omitempty" yaml:"$recursiveAnchor,omitempty"`
+	RecursiveRef    string `json:"$recursiveRef,omitempty" yaml:"$recursiveRef,omitempty"`
+	DynamicAnchor   string `json:"$dynamicAnchor,omitempty" yaml:"$dynamicAnchor,omitempty"`
+	DynamicRef      string `json:"$dynamicRef,omitempty" yaml:"$dynamicRef,omitempty"`
 
-	Type     string `json:"type,omitempty" yaml:"type,omitempty"`
-	Constant []any  `json:"constant,omitempty" yaml:"constant,omitempty"`
-	Enum     []any  `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Type     SchemaType `json:"type,omitempty" yaml:"type,omitempty"`
+	Nullable bool       `json:"-" yaml:"nullable,omitempty"`
+	Const    any        `json:"const,omitempty" yaml:"const,omitempty"`
+	Enum     []any      `json:"enum,omitempty" yaml:"enum,omitempty"`
 
-	Not   *JsonSchema   `json:"not,omitempty"`
+	Not   *JsonSchema   `json:"not,omitempty" yaml:"not,omitempty"`
 	AllOf []*JsonSchema `json:"allOf,omitempty" yaml:"allOf,omitempty"`
 	AnyOf []*JsonSchema `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
 	OneOf []*JsonSchema `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
-	If    *JsonSchema   `json:"if,omitempty"`
-	Then  *JsonSchema   `json:"then,omitempty"`
-	Else  *JsonSchema   `json:"else,omitempty"`
+	If    *JsonSchema   `json:"if,omitempty" yaml:"if,omitempty"`
+	Then  *JsonSchema   `json:"then,omitempty" yaml:"then,omitempty"`
+	Else  *JsonSchema   `json:"else,omitempty" yaml:"else,omitempty"`
 
-	MinProperties         int64                                     `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
-	MaxProperties         int64                                     `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	MinProperties         *int64                                    `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	MaxProperties         *int64                                    `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
 	Required              []string                                  `json:"required,omitempty" yaml:"required,omitempty"`
+	RequiredValue         bool                                      `json:"-" yaml:"-"`
 	Properties            *EncodableOrderedMap[string, *JsonSchema] `json:"properties,omitempty" yaml:"properties,omitempty"`
 	PropertyNames         *JsonSchema                               `json:"propertyNames,omitempty" yaml:"propertyNames,omitempty"`
-	PatternProperties     map[*regexp.Regexp]*JsonSchema            `json:"patternProperties,omitempty" yaml:"patternProperties,omitempty"`
+	PatternProperties     *EncodableOrderedMap[string, *JsonSchema] `json:"patternProperties,omitempty" yaml:"patternProperties,omitempty"`
 	AdditionalProperties  any                                       `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
 	Dependencies          map[string]any                            `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
 	DependentRequired     map[string][]string                       `json:"dependentRequired,omitempty" yaml:"dependentRequired,omitempty"`
 	DependentSchemas      map[string]*JsonSchema                    `json:"dependentSchemas,omitempty" yaml:"dependentSchemas,omitempty"`
 	UnevaluatedProperties *JsonSchema                               `json:"unevaluatedProperties,omitempty" yaml:"unevaluatedProperties,omitempty"`
 
-	MinItems         int64         `json:"minItems,omitempty" yaml:"minItems,omitempty"`
-	MaxItems         int64         `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinItems         *int64        `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems         *int64        `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
 	UniqueItems      bool          `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
 	Items            any           `json:"items,omitempty" yaml:"items,omitempty"`
 	AdditionalItems  any           `json:"additionalItems,omitempty" yaml:"additionalItems,omitempty"`
 	PrefixItems      []*JsonSchema `json:"prefixItems,omitempty" yaml:"prefixItems,omitempty"`
 	Contains         *JsonSchema   `json:"contains,omitempty" yaml:"contains,omitempty"`
-	MinContains      int64         `json:"minContains,omitempty" yaml:"minContains,omitempty"`
-	MaxContains      int64         `json:"maxContains,omitempty" yaml:"maxContains,omitempty"`
+	MinContains      *int64        `json:"minContains,omitempty" yaml:"minContains,omitempty"`
+	MaxContains      *int64        `json:"maxContains,omitempty" yaml:"maxContains,omitempty"`
 	UnevaluatedItems *JsonSchema   `json:"unevaluatedItems,omitempty" yaml:"unevaluatedItems,omitempty"`
 
-	MinLength        int64          `json:"minLength,omitempty" yaml:"minLength,omitempty"`
-	MaxLength        int64          `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
-	Pattern          *regexp.Regexp `json:"pattern,omitempty" yaml:"pattern,omitempty"`
-	ContentEncoding  string         `json:"contentEncoding,omitempty" yaml:"contentEncoding,omitempty"`
-	ContentMediaType string         `json:"contentMediaType,omitempty" yaml:"contentMediaType,omitempty"`
-	ContentSchema    *JsonSchema    `json:"contentSchema,omitempty" yaml:"contentSchema,omitempty"`
+	MinLength        *int64      `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength        *int64      `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Pattern          string      `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	ContentEncoding  string      `json:"contentEncoding,omitempty" yaml:"contentEncoding,omitempty"`
+	ContentMediaType string      `json:"contentMediaType,omitempty" yaml:"contentMediaType,omitempty"`
+	ContentSchema    *JsonSchema `json:"contentSchema,omitempty" yaml:"contentSchema,omitempty"`
 
-	Minimum          int64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
-	ExclusiveMinimum int64 `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
-	Maximum          int64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
-	ExclusiveMaximum int64 `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
-	MultipleOf       int64 `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	Minimum          Number         `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	ExclusiveMinimum ExclusiveLimit `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	Maximum          Number         `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMaximum ExclusiveLimit `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	MultipleOf       Number         `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
 
 	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 	Default     any    `json:"default,omitempty" yaml:"default,omitempty"`
-	Comment     string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Comment     string `json:"$comment,omitempty" yaml:"$comment,omitempty"`
 	ReadOnly    bool   `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
 	WriteOnly   bool   `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
 	Examples    []any  `json:"examples,omitempty" yaml:"examples,omitempty"`
 	Deprecated  bool   `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
 
-	// Extensions map[string]ExtSchema `json:"extensions,omitempty"`
+	// docs only keywords, which aren't part of the generated schema
+	DocsDefault string `json:"-" yaml:"docsDefault,omitempty"`
+	DocsSection string `json:"-" yaml:"docsSection,omitempty"`
+	DocsIgnored bool   `json:"-" yaml:"docsIgnored,omitempty"`
+
+	// Extensions holds the vendor extension keywords (x-*), in declaration order
+	Extensions *EncodableOrderedMap[string, any] `json:"-" yaml:"-"`
+}
+
+// ExtensionPrefix is the prefix of vendor extension keywords.
+const ExtensionPrefix = "x-"
+
+// jsonSchema has the fields of JsonSchema without its encoding methods.
+type jsonSchema JsonSchema
+
+// MarshalJSON encodes the schema keywords followed by the extension keywords
+// in declaration order.
+func (s *JsonSchema) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*jsonSchema)(s))
+	if err != nil || s.Extensions == nil || s.Extensions.Len() == 0 {
+		return data, err
+	}
+
+	buf := bytes.NewBuffer(bytes.TrimSuffix(data, []byte("}")))
+	for key, value := range s.Extensions.AllFromFront() {
+		keyData, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueData, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(keyData)
+		buf.WriteByte(':')
+		buf.Write(valueData)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalYAML decodes the schema keywords and collects the extension
+// keywords, which the struct has no fields for.
+func (s *JsonSchema) UnmarshalYAML(node *yaml.Node) error {
+	if err := node.Decode((*jsonSchema)(s)); err != nil {
+		return err
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if field := s.schemaKeyword(key); field != nil {
+			value, err := decodeSchemaKeyword(node.Content[i+1])
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*field = value
+			continue
+		}
+		if !strings.HasPrefix(key, ExtensionPrefix) {
+			continue
+		}
+
+		var value any
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		if s.Extensions == nil {
+			s.Extensions = NewEncodableOrderedMap[string, any]()
+		}
+		s.Extensions.Set(key, value)
+	}
+
+	return nil
+}
+
+// schemaKeyword returns the field of a keyword which holds a schema or a
+// boolean, or nil for other keywords.
+func (s *JsonSchema) schemaKeyword(key string) *any {
+	switch key {
+	case "items":
+		return &s.Items
+	case "additionalItems":
+		return &s.AdditionalItems
+	case "additionalProperties":
+		return &s.AdditionalProperties
+	default:
+		return nil
+	}
+}
+
+// decodeSchemaKeyword decodes the value of a keyword which holds a schema or a
+// boolean. Schemas are decoded as schemas rather than maps so they're walked
+// like the other subschemas, including those of an items array.
+func decodeSchemaKeyword(node *yaml.Node) (any, error) {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return decodeSchemaKeyword(node.Alias)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		s := &JsonSchema{}
+		if err := node.Decode(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case yaml.SequenceNode:
+		items := []any{}
+		for _, item := range node.Content {
+			value, err := decodeSchemaKeyword(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	default:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// Extension returns the value of the extension keyword, or nil if it isn't set.
+func (s *JsonSchema) Extension(key string) any {
+	if s.Extensions == nil {
+		return nil
+	}
+	value, _ := s.Extensions.Get(key)
+	return value
+}
+
+// Subschemas returns the schemas nested directly within the schema.
+func (s *JsonSchema) Subschemas() []*JsonSchema {
+	subschemas := []*JsonSchema{}
+	for _, m := range []*EncodableOrderedMap[string, *JsonSchema]{s.Properties, s.PatternProperties, s.Definitions, s.Defs} {
+		if m == nil {
+			continue
+		}
+		for _, v := range m.AllFromFront() {
+			subschemas = append(subschemas, v)
+		}
+	}
+
+	for _, v := range []any{s.Items, s.AdditionalItems, s.AdditionalProperties} {
+		switch v := v.(type) {
+		case *JsonSchema:
+			subschemas = append(subschemas, v)
+		case []any:
+			// an items array (draft-07)
+			for _, item := range v {
+				if item, ok := item.(*JsonSchema); ok {
+					subschemas = append(subschemas, item)
+				}
+			}
+		}
+	}
+
+	subschemas = append(subschemas, s.AllOf...)
+	subschemas = append(subschemas, s.AnyOf...)
+	subschemas = append(subschemas, s.OneOf...)
+	subschemas = append(subschemas, s.PrefixItems...)
+	for _, v := range s.DependentSchemas {
+		subschemas = append(subschemas, v)
+	}
+
+	for _, v := range []*JsonSchema{
+		s.Not, s.If, s.Then, s.Else,
+		s.PropertyNames, s.UnevaluatedProperties, s.UnevaluatedItems,
+		s.Contains, s.ContentSchema,
+	} {
+		if v != nil {
+			subschemas = append(subschemas, v)
+		}
+	}
+
+	return subschemas
+}
+
+// KeywordSchema returns the schema of a keyword which holds either a schema or
+// a boolean, like items or additionalProperties. Keywords read from a schema
+// file hold maps rather than schemas. It returns nil if the keyword is unset.
+func KeywordSchema(value any) *JsonSchema {
+	switch v := value.(type) {
+	case *JsonSchema:
+		return v
+	case bool:
+		if v {
+			return &JsonSchema{}
+		}
+		return &JsonSchema{Not: &JsonSchema{}}
+	case map[string]any:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		s := &JsonSchema{}
+		if err := yaml.Unmarshal(data, s); err != nil {
+			return nil
+		}
+		return s
+	default:
+		return nil
+	}
+}
+
+// Walk calls fn for the schema and every schema nested within it.
+func (s *JsonSchema) Walk(fn func(*JsonSchema)) {
+	fn(s)
+	for _, subschema := range s.Subschemas() {
+		subschema.Walk(fn)
+	}
 }
 
 type NodeInspector func(keyPath []*JsonSchema, schema *JsonSchema)
@@ -98,3 +332,23 @@ func (s *JsonSchema) walkProperties(fns []NodeInspector, keyPath ...*JsonSchema)
 		k.walkProperties(fns, append(keyPath, s)...)
 	}
 }
+
+// ResolveLocalRef looks up a local reference to a definitions or $defs entry
+// of the schema, eg: "#/definitions/probe".
+func (s *JsonSchema) ResolveLocalRef(ref string) (*JsonSchema, bool) {
+	for prefix, section := range map[string]*EncodableOrderedMap[string, *JsonSchema]{
+		"#/definitions/": s.Definitions,
+		"#/$defs/":       s.Defs,
+	} {
+		name, ok := strings.CutPrefix(ref, prefix)
+		if !ok || section == nil {
+			continue
+		}
+
+		// unescape the json pointer token
+		name = strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+		return section.Get(name)
+	}
+
+	return nil, false
+}
@@ -0,0 +1,9 @@
+package migrate
+
+import "github.com/sirupsen/logrus"
+
+type Config struct {
+	StdOut   bool
+	DryRun   bool
+	LogLevel logrus.Level
+}
@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"fmt"
+	"helmvalues/internal/charts"
+	"helmvalues/pkg/schema/comments"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MigrateComments rewrites the helm-schema comments in the values files of
+// the charts to native comments.
+func MigrateComments(logger *logrus.Logger, cfg *Config, chartDirs []string) error {
+	chartsFound, err := charts.Search(logger, chartDirs)
+	if err != nil {
+		return err
+	}
+
+	for _, chart := range chartsFound {
+		logger.Infof("migrate: %s: starting migration", chart.Details.Name)
+
+		content, err := os.ReadFile(chart.ValuesFilePath())
+		if err != nil {
+			return err
+		}
+
+		rewritten, count, err := comments.RewriteSchemaFences(string(content))
+		if err != nil {
+			return fmt.Errorf("%s: %w", chart.ValuesFilePath(), err)
+		}
+		logger.Infof("migrate: %s: rewrote %d comments", chart.Details.Name, count)
+
+		if cfg.StdOut {
+			fmt.Print(rewritten)
+		}
+
+		if cfg.DryRun {
+			logger.Infof("migrate: %s: dry-run enabled, skipping write to %s", chart.Details.Name, chart.ValuesFilePath())
+			continue
+		}
+
+		if count > 0 {
+			if err := os.WriteFile(chart.ValuesFilePath(), []byte(rewritten), 0644); err != nil {
+				return err
+			}
+		}
+
+		logger.Infof("migrate: %s: finished", chart.Details.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Number is a json number keyword value, kept as its json literal. Literals
+// which are already json numbers are kept as written so they round trip
+// exactly, while other yaml literals (eg: 0x1F or .5) are converted. The empty
+// Number is unset, which distinguishes it from zero.
+type Number string
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	return []byte(n), nil
+}
+
+func (n *Number) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*n = Number(number)
+	return nil
+}
+
+func (n Number) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: string(n)}, nil
+}
+
+func (n *Number) UnmarshalYAML(node *yaml.Node) error {
+	literal, err := numberLiteral(node)
+	if err != nil {
+		return err
+	}
+	*n = Number(literal)
+	return nil
+}
+
+// ExclusiveLimit is the exclusiveMinimum or exclusiveMaximum keyword, which is
+// a number since draft-06 and a boolean modifying minimum or maximum in
+// draft-04. Like Number it is kept as its json literal, and empty when unset.
+type ExclusiveLimit string
+
+func NewExclusiveLimit(exclusive bool) ExclusiveLimit {
+	return ExclusiveLimit(strconv.FormatBool(exclusive))
+}
+
+// Bool returns the draft-04 boolean form of the keyword, if it is a boolean.
+func (l ExclusiveLimit) Bool() (bool, bool) {
+	switch l {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// Number returns the draft-06 number form of the keyword, if it is a number.
+func (l ExclusiveLimit) Number() (Number, bool) {
+	if _, ok := l.Bool(); ok || l == "" {
+		return "", false
+	}
+	return Number(l), true
+}
+
+func (l ExclusiveLimit) MarshalJSON() ([]byte, error) {
+	return []byte(l), nil
+}
+
+func (l *ExclusiveLimit) UnmarshalJSON(data []byte) error {
+	var exclusive bool
+	if err := json.Unmarshal(data, &exclusive); err == nil {
+		*l = NewExclusiveLimit(exclusive)
+		return nil
+	}
+
+	var number Number
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*l = ExclusiveLimit(number)
+	return nil
+}
+
+func (l ExclusiveLimit) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: string(l)}, nil
+}
+
+func (l *ExclusiveLimit) UnmarshalYAML(node *yaml.Node) error {
+	var exclusive bool
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!bool" {
+		if err := node.Decode(&exclusive); err != nil {
+			return err
+		}
+		*l = NewExclusiveLimit(exclusive)
+		return nil
+	}
+
+	literal, err := numberLiteral(node)
+	if err != nil {
+		return err
+	}
+	*l = ExclusiveLimit(literal)
+	return nil
+}
+
+// jsonNumberPattern matches the json number grammar.
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// numberLiteral returns the json literal of a yaml int or float scalar. The
+// scalar is only converted when it isn't written as a json number, since the
+// conversion goes through float64 and can lose precision.
+func numberLiteral(node *yaml.Node) (string, error) {
+	if node.Kind != yaml.ScalarNode || (node.ShortTag() != "!!int" && node.ShortTag() != "!!float") {
+		return "", fmt.Errorf("line %d: cannot unmarshal %s into a number", node.Line, node.Value)
+	}
+	if jsonNumberPattern.MatchString(node.Value) {
+		return node.Value, nil
+	}
+
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return "", err
+	}
+
+	literal, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("line %d: %s is not a valid json number: %w", node.Line, node.Value, err)
+	}
+	return string(literal), nil
+}
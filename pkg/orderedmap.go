@@ -2,10 +2,12 @@ package pkg
 
 import (
 	"encoding/json"
+	"fmt"
 	"iter"
 	"maps"
 
 	om "github.com/elliotchance/orderedmap/v3"
+	"go.yaml.in/yaml/v4"
 )
 
 // 😮‍💨 this is stupid, but orderedmap doesn't implement json marshalling
@@ -46,3 +48,36 @@ func (m *EncodableOrderedMap[K, V]) Set(key K, value V) {
 	inner := m.ToOrderedMap()
 	inner.Set(key, value)
 }
+
+func (m *EncodableOrderedMap[K, V]) Has(key K) bool {
+	inner := m.ToOrderedMap()
+	return inner.Has(key)
+}
+
+func (m *EncodableOrderedMap[K, V]) Len() int {
+	inner := m.ToOrderedMap()
+	return inner.Len()
+}
+
+func (m *EncodableOrderedMap[K, V]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+
+	*m = *NewEncodableOrderedMap[K, V]()
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var key K
+		if err := node.Content[i].Decode(&key); err != nil {
+			return err
+		}
+
+		var value V
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+
+		m.Set(key, value)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.yaml.in/yaml/v4"
+)
+
+const MergeKeyTag = "!!merge"
+
+// MappingPairs returns the key/value pairs of the mapping node with merge keys
+// expanded the way helm expands them. Keys declared in the mapping take
+// precedence over merged keys, and earlier merge sources take precedence over
+// later ones. Merged keys are positioned where the merge key was declared.
+func MappingPairs(value *yaml.Node) ([][]*yaml.Node, error) {
+	explicit := map[string]bool{}
+	for _, pair := range lo.Chunk(value.Content, 2) {
+		if !isMergeKey(pair[0]) {
+			explicit[pair[0].Value] = true
+		}
+	}
+
+	merged := map[string]bool{}
+	pairs := [][]*yaml.Node{}
+	for _, pair := range lo.Chunk(value.Content, 2) {
+		if !isMergeKey(pair[0]) {
+			pairs = append(pairs, pair)
+			continue
+		}
+
+		sources, err := mergeSources(pair[1])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, source := range sources {
+			sourcePairs, err := MappingPairs(source)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, sourcePair := range sourcePairs {
+				if explicit[sourcePair[0].Value] || merged[sourcePair[0].Value] {
+					continue
+				}
+				merged[sourcePair[0].Value] = true
+				pairs = append(pairs, sourcePair)
+			}
+		}
+	}
+
+	return pairs, nil
+}
+
+// mergeSources returns the mapping nodes referenced by a merge key value, which
+// is either a single alias or a sequence of aliases.
+func mergeSources(value *yaml.Node) ([]*yaml.Node, error) {
+	candidates := []*yaml.Node{value}
+	if value.Kind == yaml.SequenceNode {
+		candidates = value.Content
+	}
+
+	sources := []*yaml.Node{}
+	for _, candidate := range candidates {
+		source := ResolveAlias(candidate)
+		if source.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("merge key on line %d must reference a mapping", value.Line)
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+func isMergeKey(key *yaml.Node) bool {
+	return key.Kind == yaml.ScalarNode && (key.Tag == MergeKeyTag || (key.Tag == "" && key.Value == "<<"))
+}
+
+// ResolveAlias follows the alias node to the anchored node.
+func ResolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// ResolvedCopy returns a deep copy of the node with aliases replaced by the
+// anchored nodes and merge keys expanded, so it can be encoded on its own.
+func ResolvedCopy(node *yaml.Node) (*yaml.Node, error) {
+	return resolvedCopyOf(node, map[*yaml.Node]bool{})
+}
+
+func resolvedCopyOf(node *yaml.Node, visiting map[*yaml.Node]bool) (*yaml.Node, error) {
+	node = ResolveAlias(node)
+	if visiting[node] {
+		return nil, fmt.Errorf("recursive alias on line %d", node.Line)
+	}
+	visiting[node] = true
+	defer delete(visiting, node)
+
+	c := *node
+	c.Anchor = ""
+	c.Content = nil
+
+	children := node.Content
+	if node.Kind == yaml.MappingNode {
+		pairs, err := MappingPairs(node)
+		if err != nil {
+			return nil, err
+		}
+		children = lo.Flatten(pairs)
+	}
+
+	for _, child := range children {
+		childCopy, err := resolvedCopyOf(child, visiting)
+		if err != nil {
+			return nil, err
+		}
+		c.Content = append(c.Content, childCopy)
+	}
+
+	return &c, nil
+}
+
+// anchorKeys maps each anchored value node to the key node holding its doc
+// comment, so aliases of the anchor can inherit the comment. Anchored sequence
+// items hold their own comments.
+func anchorKeys(node *yaml.Node) map[*yaml.Node]*yaml.Node {
+	keys := map[*yaml.Node]*yaml.Node{}
+
+	var walk func(key *yaml.Node, value *yaml.Node)
+	walk = func(key *yaml.Node, value *yaml.Node) {
+		if value.Anchor != "" {
+			keys[value] = commentNode(key, value)
+		}
+
+		switch value.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range value.Content {
+				walk(nil, child)
+			}
+		case yaml.MappingNode:
+			for _, pair := range lo.Chunk(value.Content, 2) {
+				walk(pair[0], pair[1])
+			}
+		}
+	}
+	walk(nil, node)
+
+	return keys
+}
@@ -0,0 +1,234 @@
+package schema
+
+import (
+	"fmt"
+	"helmvalues/pkg"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+)
+
+// RefBundler copies the targets of non-local $refs into the root schema's
+// definitions and rewrites the refs to point at the copies, so the schema can
+// be used without network access. Relative refs are read from files relative
+// to the chart, and remote refs are read from the ref cache directory where
+// documents are stored by host and path (eg: <cache>/example.com/schemas/probe.json).
+type RefBundler struct {
+	logger    *logrus.Logger
+	chartRoot string
+	cacheDir  string
+
+	// documents holds the parsed documents by location
+	documents map[string]*yaml.Node
+	// names holds the definition names of bundled refs by absolute ref
+	names map[string]string
+}
+
+func NewRefBundler(logger *logrus.Logger, chartRoot string, cacheDir string) *RefBundler {
+	return &RefBundler{
+		logger:    logger,
+		chartRoot: chartRoot,
+		cacheDir:  cacheDir,
+		documents: map[string]*yaml.Node{},
+		names:     map[string]string{},
+	}
+}
+
+// Bundle rewrites every non-local ref in the root schema to a bundled definition.
+func (b *RefBundler) Bundle(root *pkg.JsonSchema) error {
+	base := (&url.URL{Scheme: "file", Path: filepath.ToSlash(b.chartRoot) + "/"}).String()
+	return b.bundleRefs(root, root, base)
+}
+
+// bundleRefs bundles the refs within the schema, resolving relative refs
+// against the location of the document the schema was read from.
+func (b *RefBundler) bundleRefs(root *pkg.JsonSchema, s *pkg.JsonSchema, base string) error {
+	refSchemas := []*pkg.JsonSchema{}
+	s.Walk(func(sub *pkg.JsonSchema) {
+		if sub.Ref != "" {
+			refSchemas = append(refSchemas, sub)
+		}
+	})
+
+	for _, sub := range refSchemas {
+		// refs local to the values schema already resolve
+		if s == root && strings.HasPrefix(sub.Ref, "#") {
+			continue
+		}
+
+		ref, err := resolveRef(base, sub.Ref)
+		if err != nil {
+			return err
+		}
+
+		name, err := b.bundleRef(root, ref)
+		if err != nil {
+			return err
+		}
+
+		b.logger.Debugf("bundle: rewriting $ref %s to %s", sub.Ref, definitionRef(name))
+		sub.Ref = definitionRef(name)
+	}
+
+	return nil
+}
+
+// bundleRef copies the target of the absolute ref into the root definitions,
+// returning the definition name.
+func (b *RefBundler) bundleRef(root *pkg.JsonSchema, ref *url.URL) (string, error) {
+	if name, ok := b.names[ref.String()]; ok {
+		return name, nil
+	}
+
+	location := *ref
+	location.Fragment = ""
+	document, err := b.readDocument(&location)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve $ref %s offline: %w", ref, err)
+	}
+
+	target, err := resolvePointer(document, ref.Fragment)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve $ref %s: %w", ref, err)
+	}
+
+	// decoding from the document node gives each definition its own copy
+	definition := &pkg.JsonSchema{}
+	if err := target.Decode(definition); err != nil {
+		return "", fmt.Errorf("cannot read $ref %s: %w", ref, err)
+	}
+	// definitions nested in the target are bundled separately when referenced
+	definition.Definitions = nil
+	definition.Defs = nil
+	definition.Schema = ""
+	definition.ID = ""
+
+	if root.Definitions == nil {
+		root.Definitions = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+	}
+	name := refDefinitionName(ref)
+	for i := 2; root.Definitions.Has(name); i++ {
+		name = fmt.Sprintf("%s-%d", refDefinitionName(ref), i)
+	}
+
+	// registered before bundling nested refs so recursive refs resolve
+	b.names[ref.String()] = name
+	root.Definitions.Set(name, definition)
+
+	if err := b.bundleRefs(root, definition, location.String()); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// readDocument reads the document at the location from disk, using the ref
+// cache for remote documents.
+func (b *RefBundler) readDocument(location *url.URL) (*yaml.Node, error) {
+	if document, ok := b.documents[location.String()]; ok {
+		return document, nil
+	}
+
+	var filePath string
+	switch location.Scheme {
+	case "file":
+		filePath = filepath.FromSlash(location.Path)
+	case "http", "https":
+		if b.cacheDir == "" {
+			return nil, fmt.Errorf("no ref cache directory configured")
+		}
+		filePath = filepath.Join(b.cacheDir, location.Host, filepath.FromSlash(location.Path))
+	default:
+		return nil, fmt.Errorf("unsupported scheme %s", location.Scheme)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s does not exist", filePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal(content, document); err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	if len(document.Content) == 0 {
+		return nil, fmt.Errorf("%s is empty", filePath)
+	}
+
+	b.documents[location.String()] = document.Content[0]
+	return document.Content[0], nil
+}
+
+// definitionRef returns the local ref to the named definition.
+func definitionRef(name string) string {
+	return "#/definitions/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func resolveRef(base string, ref string) (*url.URL, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %s: %w", ref, err)
+	}
+
+	return baseURL.ResolveReference(refURL), nil
+}
+
+// resolvePointer follows the json pointer fragment through the document.
+func resolvePointer(document *yaml.Node, fragment string) (*yaml.Node, error) {
+	node := document
+	if fragment == "" || fragment == "/" {
+		return node, nil
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			var idx int
+			if _, err := fmt.Sscanf(token, "%d", &idx); err == nil && idx >= 0 && idx < len(node.Content) {
+				next = node.Content[idx]
+			}
+		}
+
+		if next == nil {
+			return nil, fmt.Errorf("pointer #%s not found", fragment)
+		}
+		node = next
+	}
+
+	return node, nil
+}
+
+// refDefinitionName names the definition after the last pointer token, or the
+// document's file name when the ref has no fragment.
+func refDefinitionName(ref *url.URL) string {
+	if ref.Fragment != "" && ref.Fragment != "/" {
+		tokens := strings.Split(ref.Fragment, "/")
+		return strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[len(tokens)-1])
+	}
+
+	base := path.Base(ref.Path)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
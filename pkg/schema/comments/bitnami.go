@@ -0,0 +1,137 @@
+package comments
+
+import (
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+var (
+	bitnamiParam   = regexp.MustCompile(`^##\s*@(param|extra)\s+(\S+)\s*(?:\[([^\]]*)\])?\s*(.*)$`)
+	bitnamiSkip    = regexp.MustCompile(`^##\s*@skip\s+(\S+)`)
+	bitnamiSection = regexp.MustCompile(`^##\s*@section\s+(.*)$`)
+)
+
+// BitnamiReader reads comments written for Bitnami's readme-generator:
+//
+//	## @section Image parameters
+//	## @param image.repository Image repository
+//	## @param image.tag [string, nullable, default: appVersion] Image tag
+//	## @skip image.digest
+//	## @extra image.pullSecrets Image pull secrets, not set in the values
+//
+// The annotations are matched to values by key path, so they can be placed
+// anywhere in the file. Sections apply to the annotations that follow them.
+// Other comments starting with # are read as native comments, while the
+// remaining ## comments are ignored.
+type BitnamiReader struct {
+	// params holds the schema keyword nodes of the annotations by key path
+	params map[string][]*yaml.Node
+	extras []ExtraValue
+	native NativeReader
+}
+
+func NewBitnamiReader(document *yaml.Node, native NativeReader) *BitnamiReader {
+	r := &BitnamiReader{params: map[string][]*yaml.Node{}, native: native}
+	section := ""
+	r.collectAnnotations(document, &section)
+	return r
+}
+
+func (r *BitnamiReader) CommentNodes(node *yaml.Node, path string) ([]*yaml.Node, error) {
+	lines := []string{}
+	for _, line := range strings.Split(node.HeadComment, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "##") {
+			lines = append(lines, line)
+		}
+	}
+
+	// the comment is re-read without the readme-generator comments it contained
+	commentNodes := []*yaml.Node{}
+	if comment := strings.Trim(strings.Join(lines, "\n"), "\n"); comment != "" {
+		nativeNode := *node
+		nativeNode.HeadComment = comment
+		nodes, err := r.native.CommentNodes(&nativeNode, path)
+		if err != nil {
+			return nil, err
+		}
+		commentNodes = nodes
+	}
+
+	// annotations take precedence over native comments
+	params := r.params[path]
+	return append(withoutKeys(commentNodes, params), params...), nil
+}
+
+func (r *BitnamiReader) ExtraValues() []ExtraValue {
+	return r.extras
+}
+
+// collectAnnotations reads the annotations from every comment in the document,
+// in document order.
+func (r *BitnamiReader) collectAnnotations(node *yaml.Node, section *string) {
+	if node == nil {
+		return
+	}
+
+	r.readAnnotations(node, node.HeadComment, section)
+	r.readAnnotations(node, node.LineComment, section)
+	for _, child := range node.Content {
+		r.collectAnnotations(child, section)
+	}
+	r.readAnnotations(node, node.FootComment, section)
+}
+
+func (r *BitnamiReader) readAnnotations(node *yaml.Node, comment string, section *string) {
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+
+		if match := bitnamiSection.FindStringSubmatch(line); match != nil {
+			*section = strings.TrimSpace(match[1])
+			continue
+		}
+		if match := bitnamiSkip.FindStringSubmatch(line); match != nil {
+			r.params[match[1]] = KeyValueNodes("docsIgnored", "true")
+			continue
+		}
+
+		match := bitnamiParam.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		kind, path, modifiers, description := match[1], match[2], match[3], strings.TrimSpace(match[4])
+
+		nodes := modifierNodes(modifiers)
+		if description != "" {
+			nodes = append(stringNodes("description", description), nodes...)
+		}
+		if *section != "" {
+			nodes = append(nodes, stringNodes("docsSection", *section)...)
+		}
+		r.params[path] = nodes
+
+		if kind == "extra" {
+			r.extras = append(r.extras, ExtraValue{Path: path, Line: node.Line})
+		}
+	}
+}
+
+// modifierNodes converts the comma separated modifiers of an annotation, eg:
+// [array, nullable, default: foo], to schema keyword nodes.
+func modifierNodes(modifiers string) []*yaml.Node {
+	nodes := []*yaml.Node{}
+	for _, modifier := range strings.Split(modifiers, ",") {
+		modifier = strings.TrimSpace(modifier)
+		switch {
+		case modifier == "array", modifier == "object", modifier == "string":
+			nodes = append(nodes, KeyValueNodes("type", modifier)...)
+		case modifier == "nullable":
+			nodes = append(nodes, KeyValueNodes("nullable", "true")...)
+		case strings.HasPrefix(modifier, "default:"):
+			value := strings.TrimSpace(strings.TrimPrefix(modifier, "default:"))
+			nodes = append(nodes, stringNodes("docsDefault", value)...)
+		}
+	}
+	return nodes
+}
@@ -3,36 +3,35 @@ package comments
 import (
 	"fmt"
 	"helmvalues/pkg"
+	"slices"
 	"strings"
 
 	"go.yaml.in/yaml/v4"
 )
 
 func Parse(node *yaml.Node, extraNodes []*yaml.Node) (*pkg.JsonSchema, error) {
-	// new yaml map node to append the schema field nodes to
-	schemaMapNode := &yaml.Node{
-		Kind:    yaml.MappingNode,
-		Content: extraNodes,
+	return ParseWith(NativeReader{}, node, "", extraNodes)
+}
+
+// ParseWith parses the comment of the node at the key path with the reader of
+// a comment dialect.
+func ParseWith(reader CommentReader, node *yaml.Node, path string, extraNodes []*yaml.Node) (*pkg.JsonSchema, error) {
+	commentNodes, err := reader.CommentNodes(node, path)
+	if err != nil {
+		return nil, err
 	}
 
-	if node.HeadComment != "" {
-		commentDocs, err := parseNodeComment(node)
-		if err != nil {
-			return nil, err
-		}
+	requiredValue := false
+	if nodes, required, ok := cutRequiredValue(commentNodes); ok {
+		commentNodes = nodes
+		requiredValue = required
+	}
 
-		for _, commentDoc := range commentDocs {
-			nodes, ok := commentAsDescriptionNodes(commentDoc)
-			if ok {
-				schemaMapNode.Content = append(schemaMapNode.Content, nodes...)
-				continue
-			}
-
-			nodes, ok = commentAsMapNodes(commentDoc)
-			if ok {
-				schemaMapNode.Content = append(schemaMapNode.Content, nodes...)
-			}
-		}
+	// new yaml map node to append the schema field nodes to. Fields declared
+	// in the comment take precedence over the generated extra nodes.
+	schemaMapNode := &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: append(withoutKeys(extraNodes, commentNodes), commentNodes...),
 	}
 
 	// marshal to a string and subsequently unmarshal into the schema
@@ -42,34 +41,103 @@ func Parse(node *yaml.Node, extraNodes []*yaml.Node) (*pkg.JsonSchema, error) {
 	}
 
 	s := &pkg.JsonSchema{}
-	err = yaml.Unmarshal(fullSchema, s)
+	if err := yaml.Unmarshal(fullSchema, s); err != nil {
+		return s, err
+	}
+
+	s.RequiredValue = requiredValue
+
+	// nullable is shorthand for including null in the declared type
+	if s.Nullable && len(s.Type) > 0 {
+		s.Type = s.Type.WithNull()
+		s.Nullable = false
+	}
+
+	if err := validatePatterns(node, s); err != nil {
+		return s, NewCommentError(node, err)
+	}
+
+	// the schema is still returned so callers can choose to ignore the keywords
+	if unknown := unknownKeywords(commentNodes); len(unknown) > 0 {
+		err := fmt.Errorf("%w: %s", ErrUnknownKeyword, strings.Join(unknown, ", "))
+		return s, NewCommentError(node, err)
+	}
 
-	return s, err
+	return s, nil
 }
 
-func parseNodeComment(node *yaml.Node) ([]string, error) {
-	targetComment := node.HeadComment
+// withoutKeys returns the key/value pairs in nodes whose keys are not present
+// in the key/value pairs of exclude.
+func withoutKeys(nodes []*yaml.Node, exclude []*yaml.Node) []*yaml.Node {
+	excluded := map[string]bool{}
+	for i := 0; i+1 < len(exclude); i += 2 {
+		excluded[exclude[i].Value] = true
+	}
+
+	result := []*yaml.Node{}
+	for i := 0; i+1 < len(nodes); i += 2 {
+		if excluded[nodes[i].Value] {
+			continue
+		}
+		result = append(result, nodes[i], nodes[i+1])
+	}
+	return result
+}
+
+// DocumentSeparator is a comment line separating the yaml documents of a doc
+// comment.
+const DocumentSeparator = "---"
+
+// DefaultDetachedMarker starts the comment paragraphs which are detached from
+// the key below them.
+const DefaultDetachedMarker = "##"
+
+// docCommentLines returns the lines of the head comment making up the doc
+// comment, which are the paragraphs above the key up to the first paragraph
+// starting with the detached marker. Blank lines between the paragraphs are
+// kept.
+func docCommentLines(comment string, detachedMarker string) []string {
+	lines := strings.Split(comment, "\n")
 
-	// split the comment by double newline
-	parts := strings.Split(targetComment, "\n\n")
-	if len(parts) > 1 {
-		targetComment = parts[len(parts)-1]
+	start := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if i > 0 && lines[i-1] != "" {
+			continue
+		}
+		// lines[i] starts a paragraph
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), detachedMarker) {
+			break
+		}
+		start = i
 	}
 
-	commentLines := strings.Split(targetComment, "\n")
-	for i, line := range commentLines {
+	return slices.Clone(lines[start:])
+}
+
+// parseNodeComment splits the doc comment lines into yaml documents. Blank
+// lines, and comment lines without any text, are kept as blank lines.
+func parseNodeComment(node *yaml.Node, lines []string) ([]string, error) {
+	commentDocs := []string{}
+	docLines := []string{}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" || strings.TrimSpace(line) == "#" {
+			docLines = append(docLines, "")
+			continue
+		}
+
 		after, found := strings.CutPrefix(line, "# ")
 		if !found {
-			err := fmt.Errorf("unexpected prefix: %s (%d of %d lines)", line, i, len(commentLines))
+			err := fmt.Errorf("unexpected prefix: %s (%d of %d lines)", line, i, len(lines))
 			return nil, NewCommentError(node, err)
 		}
-		commentLines[i] = after
-	}
 
-	commentDocs := strings.Split(
-		strings.Join(commentLines, "\n"),
-		"---",
-	)
+		if strings.TrimSpace(after) == DocumentSeparator {
+			commentDocs = append(commentDocs, strings.Join(docLines, "\n"))
+			docLines = []string{}
+			continue
+		}
+		docLines = append(docLines, after)
+	}
 
-	return commentDocs, nil
+	return append(commentDocs, strings.Join(docLines, "\n")), nil
 }
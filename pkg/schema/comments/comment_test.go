@@ -3,10 +3,11 @@ package comments
 import (
 	"fmt"
 	"helmvalues/pkg"
+	"slices"
+	"strings"
 	"testing"
 
-	"regexp"
-
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"go.yaml.in/yaml/v4"
 )
@@ -50,6 +51,40 @@ const SETS_DESCRIPTION_TO_SECOND_DOC = `
 foo: bar
 `
 
+const DETACHED_COMMENTS = `
+foo: bar
+
+## -------- Image settings --------
+
+# type: string
+
+# The image to run.
+#
+# Markdown rules are kept:
+#
+# ----
+#
+# | a | b |
+# |---|---|
+image: nginx
+`
+
+const CUSTOM_DETACHED_MARKER = `
+foo: bar
+
+# ==== Image settings ====
+
+# The image to run
+image: nginx
+`
+
+const SEPARATOR_WITHIN_LINE = `
+# default: foo---bar
+# ---
+# Joined like foo---bar
+foo: bar
+`
+
 func TestBasicCommentParsing(t *testing.T) {
 	var tests = []struct {
 		name          string
@@ -74,12 +109,11 @@ func TestBasicCommentParsing(t *testing.T) {
 			},
 		},
 		{
-			// TODO: Fix comment parsing so that the description is correctly extracted
-			name:     "errors when comment is invalid yaml string",
+			name:     "comment with invalid yaml string is treated as description",
 			document: COMMENT_WITH_INVALID_YAML,
 			validate: func(tt *testing.T, s *pkg.JsonSchema, err error) {
 				assert.NoError(tt, err)
-				assert.Equal(tt, "", s.Description)
+				assert.Equal(tt, "@invalid yaml string", s.Description)
 			},
 		},
 		{
@@ -91,10 +125,11 @@ func TestBasicCommentParsing(t *testing.T) {
 			},
 		},
 		{
-			name:     "comment has no jsonschema properties",
+			name:     "errors when comment has unknown jsonschema properties",
 			document: DOESNT_SET_SCHEMA_PROPERTIES,
 			validate: func(tt *testing.T, s *pkg.JsonSchema, err error) {
-				assert.NoError(tt, err)
+				assert.ErrorIs(tt, err, ErrUnknownKeyword)
+				assert.ErrorContains(tt, err, "unknown schema keyword: key")
 				assert.Equal(tt, pkg.JsonSchema{}, *s)
 			},
 		},
@@ -174,10 +209,45 @@ func TestCommentFieldsSingleLine(t *testing.T) {
 				assert.Equal(tt, tc.expectedValue, s.Format)
 			},
 		},
+		{
+			field:         "const",
+			commentValue:  "some value",
+			expectedValue: "some value",
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.Equal(tt, tc.expectedValue, s.Const)
+			},
+		},
+		{
+			field:         "$comment",
+			commentValue:  "some comment",
+			expectedValue: "some comment",
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.Comment)
+				assert.Equal(tt, tc.expectedValue, s.Comment)
+			},
+		},
+		{
+			field:         "$anchor",
+			commentValue:  "some-anchor",
+			expectedValue: "some-anchor",
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.Anchor)
+				assert.Equal(tt, tc.expectedValue, s.Anchor)
+			},
+		},
+		{
+			field:         "$dynamicRef",
+			commentValue:  "\"#meta\"",
+			expectedValue: "#meta",
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.DynamicRef)
+				assert.Equal(tt, tc.expectedValue, s.DynamicRef)
+			},
+		},
 		{
 			field:         "minLength",
 			commentValue:  "5",
-			expectedValue: int64(5),
+			expectedValue: lo.ToPtr(int64(5)),
 			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
 				assert.IsType(tt, tc.expectedValue, s.MinLength)
 				assert.Equal(tt, tc.expectedValue, s.MinLength)
@@ -201,15 +271,85 @@ func TestCommentFieldsSingleLine(t *testing.T) {
 				assert.Equal(tt, tc.expectedValue, s.Required)
 			},
 		},
+		{
+			field:         "type",
+			commentValue:  "[string, null]",
+			expectedValue: pkg.NewSchemaType("string", "null"),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.Type)
+				assert.Equal(tt, tc.expectedValue, s.Type)
+			},
+		},
+		{
+			field:         "minItems",
+			commentValue:  "0",
+			expectedValue: lo.ToPtr(int64(0)),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.MinItems)
+				assert.Equal(tt, tc.expectedValue, s.MinItems)
+			},
+		},
 		{
 			field:         "maximum",
 			commentValue:  "100",
-			expectedValue: int64(100),
+			expectedValue: pkg.Number("100"),
 			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
 				assert.IsType(tt, tc.expectedValue, s.Maximum)
 				assert.Equal(tt, tc.expectedValue, s.Maximum)
 			},
 		},
+		{
+			field:         "maximum",
+			commentValue:  "123456789012345678901234.5678901234567890123",
+			expectedValue: pkg.Number("123456789012345678901234.5678901234567890123"),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.Equal(tt, tc.expectedValue, s.Maximum)
+			},
+		},
+		{
+			field:         "minimum",
+			commentValue:  "0x1F",
+			expectedValue: pkg.Number("31"),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.Equal(tt, tc.expectedValue, s.Minimum)
+			},
+		},
+		{
+			field:         "minimum",
+			commentValue:  "0.5",
+			expectedValue: pkg.Number("0.5"),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.Minimum)
+				assert.Equal(tt, tc.expectedValue, s.Minimum)
+			},
+		},
+		{
+			field:         "multipleOf",
+			commentValue:  "0.25",
+			expectedValue: pkg.Number("0.25"),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.MultipleOf)
+				assert.Equal(tt, tc.expectedValue, s.MultipleOf)
+			},
+		},
+		{
+			field:         "exclusiveMinimum",
+			commentValue:  "true",
+			expectedValue: pkg.NewExclusiveLimit(true),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.ExclusiveMinimum)
+				assert.Equal(tt, tc.expectedValue, s.ExclusiveMinimum)
+			},
+		},
+		{
+			field:         "exclusiveMaximum",
+			commentValue:  "1e3",
+			expectedValue: pkg.ExclusiveLimit("1e3"),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.ExclusiveMaximum)
+				assert.Equal(tt, tc.expectedValue, s.ExclusiveMaximum)
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -257,11 +397,24 @@ const TEST_DEPENDENCIES = `
 foo: bar
 `
 
+const TEST_NULLABLE = `
+# type: string
+# nullable: true
+foo: bar
+`
+
 const TEST_PATTERN = `
 # pattern: ^[a-z]+$
 foo: bar
 `
 
+const TEST_PATTERN_PROPERTIES = `
+# patternProperties:
+#   "^app\\.kubernetes\\.io/":
+#     type: string
+foo: {}
+`
+
 func TestCommentFieldsMultipleLines(t *testing.T) {
 	type testCase struct {
 		name          string
@@ -275,8 +428,8 @@ func TestCommentFieldsMultipleLines(t *testing.T) {
 			name:    "oneOf with multiple lines",
 			comment: TEST_FIELD_ONEOF,
 			expectedValue: []*pkg.JsonSchema{
-				{Type: "string", Description: "this is a string"},
-				{Type: "number", Description: "this is a number"},
+				{Type: pkg.NewSchemaType("string"), Description: "this is a string"},
+				{Type: pkg.NewSchemaType("number"), Description: "this is a number"},
 			},
 			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
 				assert.IsType(tt, tc.expectedValue, s.OneOf)
@@ -308,15 +461,35 @@ func TestCommentFieldsMultipleLines(t *testing.T) {
 				assert.Equal(tt, tc.expectedValue, s.Dependencies)
 			},
 		},
+		{
+			name:          "nullable",
+			comment:       TEST_NULLABLE,
+			expectedValue: pkg.NewSchemaType("string", "null"),
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.IsType(tt, tc.expectedValue, s.Type)
+				assert.Equal(tt, tc.expectedValue, s.Type)
+				assert.False(tt, s.Nullable)
+			},
+		},
 		{
 			name:          "pattern",
 			comment:       TEST_PATTERN,
-			expectedValue: regexp.MustCompile("^[a-z]+$"),
+			expectedValue: "^[a-z]+$",
 			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
 				assert.IsType(tt, tc.expectedValue, s.Pattern)
 				assert.Equal(tt, tc.expectedValue, s.Pattern)
 			},
 		},
+		{
+			name:          "patternProperties",
+			comment:       TEST_PATTERN_PROPERTIES,
+			expectedValue: []string{`^app\.kubernetes\.io/`},
+			validate: func(tt *testing.T, tc testCase, s *pkg.JsonSchema) {
+				assert.Equal(tt, tc.expectedValue, slices.Collect(s.PatternProperties.Keys()))
+				prop, _ := s.PatternProperties.Get(`^app\.kubernetes\.io/`)
+				assert.Equal(tt, pkg.NewSchemaType("string"), prop.Type)
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -341,3 +514,473 @@ func getCommentNode(node *yaml.Node) *yaml.Node {
 	// Get the first scalar node in the document
 	return node.Content[0].Content[0]
 }
+
+const OVERRIDES_EXTRA_NODES = `
+# type: integer
+foo: bar
+`
+
+const BANNER_ABOVE_KEYWORDS = `
+foo: 1
+
+# Container image settings
+# for the main workload
+
+# minLength: 1
+# ---
+# the image
+image: nginx
+`
+
+func TestDocCommentParagraphs(t *testing.T) {
+	yamlNode := &yaml.Node{}
+	err := yaml.Unmarshal([]byte(DETACHED_COMMENTS), yamlNode)
+	assert.NoError(t, err)
+	image := yamlNode.Content[0].Content[2]
+
+	s, err := Parse(image, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.NewSchemaType("string"), s.Type)
+	assert.Equal(t, "The image to run.\n\nMarkdown rules are kept:\n\n----\n\n| a | b |\n|---|---|", s.Description)
+
+	yamlNode = &yaml.Node{}
+	err = yaml.Unmarshal([]byte(CUSTOM_DETACHED_MARKER), yamlNode)
+	assert.NoError(t, err)
+	image = yamlNode.Content[0].Content[2]
+
+	s, err = Parse(image, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "==== Image settings ====\n\nThe image to run", s.Description)
+
+	s, err = ParseWith(NativeReader{DetachedMarker: "# ===="}, image, "image", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "The image to run", s.Description)
+
+	yamlNode = &yaml.Node{}
+	err = yaml.Unmarshal([]byte(SEPARATOR_WITHIN_LINE), yamlNode)
+	assert.NoError(t, err)
+
+	s, err = Parse(getCommentNode(yamlNode), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo---bar", s.Default)
+	assert.Equal(t, "Joined like foo---bar", s.Description)
+
+	yamlNode = &yaml.Node{}
+	err = yaml.Unmarshal([]byte(BANNER_ABOVE_KEYWORDS), yamlNode)
+	assert.NoError(t, err)
+	image = yamlNode.Content[0].Content[2]
+
+	s, err = Parse(image, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, lo.ToPtr(int64(1)), s.MinLength)
+	assert.Equal(t, "Container image settings\nfor the main workload\n\nthe image", s.Description)
+}
+
+func TestCommentOverridesExtraNodes(t *testing.T) {
+	yamlNode := &yaml.Node{}
+	err := yaml.Unmarshal([]byte(OVERRIDES_EXTRA_NODES), yamlNode)
+	assert.NoError(t, err)
+
+	extraNodes := append(KeyValueNodes("type", "string"), KeyValueNodes("title", "foo")...)
+	s, err := Parse(getCommentNode(yamlNode), extraNodes)
+	assert.NoError(t, err)
+	assert.Equal(t, pkg.NewSchemaType("integer"), s.Type)
+	assert.Equal(t, "foo", s.Title)
+}
+
+func TestPatternCompatibility(t *testing.T) {
+	var tests = []struct {
+		pattern       string
+		expectedError string
+	}{
+		{pattern: `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`},
+		{pattern: `^\p{L}+$`},
+		{pattern: `^(?<major>\d+)\.(?=\d)`},
+		{pattern: `^[\]\[]+$`},
+		{pattern: `^(?!latest$).+$`},
+		{pattern: `^(a)\1$`},
+		{pattern: `^[]a]+$`, expectedError: "[] is an empty class"},
+		{pattern: `^[^]a]+$`, expectedError: "[^] matches any character"},
+		{pattern: `\Afoo\z`, expectedError: `\A anchors are not supported`},
+		{pattern: `^(?P<name>\w+)$`, expectedError: "named groups must be written (?<name>...)"},
+		{pattern: `(?i)^foo$`, expectedError: "inline flags like (?i) are not supported"},
+		{pattern: `^[[:alpha:]]+$`, expectedError: "POSIX character classes"},
+		{pattern: `^\pL+$`, expectedError: `unicode classes must be written \p{...}`},
+		{pattern: `\Q.*\E`, expectedError: `\Q...\E literal quoting is not supported`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(tt *testing.T) {
+			document := fmt.Sprintf("# type: string\n# pattern: '%s'\nfoo: bar\n", tc.pattern)
+
+			yamlNode := &yaml.Node{}
+			err := yaml.Unmarshal([]byte(document), yamlNode)
+			assert.NoError(tt, err)
+
+			s, err := Parse(yamlNode.Content[0].Content[0], nil)
+			if tc.expectedError == "" {
+				assert.NoError(tt, err)
+				assert.Equal(tt, tc.pattern, s.Pattern)
+				return
+			}
+
+			assert.ErrorIs(tt, err, ErrIncompatiblePattern)
+			assert.ErrorContains(tt, err, "line 2: ")
+			assert.ErrorContains(tt, err, tc.expectedError)
+		})
+	}
+}
+
+func TestPatternSyntax(t *testing.T) {
+	var tests = []struct {
+		pattern       string
+		expectedError string
+	}{
+		{pattern: `^(foo$`, expectedError: "missing closing )"},
+		{pattern: `^foo)$`, expectedError: "unexpected )"},
+		{pattern: `^[a-z$`, expectedError: "missing closing ]"},
+		{pattern: `^[z-a]$`, expectedError: "invalid character class range"},
+		{pattern: `*foo`, expectedError: "missing argument to repetition operator"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(tt *testing.T) {
+			document := fmt.Sprintf("# type: string\n# pattern: '%s'\nfoo: bar\n", tc.pattern)
+
+			yamlNode := &yaml.Node{}
+			err := yaml.Unmarshal([]byte(document), yamlNode)
+			assert.NoError(tt, err)
+
+			_, err = Parse(yamlNode.Content[0].Content[0], nil)
+			assert.ErrorIs(tt, err, ErrInvalidPattern)
+			assert.ErrorContains(tt, err, "line 2: ")
+			assert.ErrorContains(tt, err, tc.expectedError)
+		})
+	}
+}
+
+const HELM_DOCS_VALUES = `
+# foo.path -- described by path
+foo:
+  # -- the image
+  # pulled by the kubelet
+  image: nginx
+  # -- raw text
+  # @raw
+  # line two
+  raw: text
+  # -- the replicas
+  # @default -- one per node
+  # @section -- Scaling
+  replicas: 1
+  # -- internal
+  # @ignored
+  internal: true
+  path: value
+  # type: string
+  native: value
+`
+
+func TestHelmDocsDialect(t *testing.T) {
+	var tests = []struct {
+		name     string
+		path     string
+		validate func(tt *testing.T, s *pkg.JsonSchema)
+	}{
+		{
+			name: "description continues on following lines",
+			path: "image",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "the image pulled by the kubelet", s.Description)
+			},
+		},
+		{
+			name: "raw description keeps line breaks",
+			path: "raw",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "raw text\nline two", s.Description)
+			},
+		},
+		{
+			name: "default and section annotations",
+			path: "replicas",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "the replicas", s.Description)
+				assert.Equal(tt, "one per node", s.DocsDefault)
+				assert.Equal(tt, "Scaling", s.DocsSection)
+			},
+		},
+		{
+			name: "ignored annotation",
+			path: "internal",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "internal", s.Description)
+				assert.True(tt, s.DocsIgnored)
+			},
+		},
+		{
+			name: "key path comment",
+			path: "path",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "described by path", s.Description)
+			},
+		},
+		{
+			name: "native comments are still read",
+			path: "native",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, pkg.NewSchemaType("string"), s.Type)
+			},
+		},
+	}
+
+	yamlNode := &yaml.Node{}
+	err := yaml.Unmarshal([]byte(HELM_DOCS_VALUES), yamlNode)
+	assert.NoError(t, err)
+
+	reader := NewCommentReader(DialectHelmDocs, yamlNode, "")
+	foo := yamlNode.Content[0].Content[1]
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			var keyNode *yaml.Node
+			for i := 0; i+1 < len(foo.Content); i += 2 {
+				if foo.Content[i].Value == tc.path {
+					keyNode = foo.Content[i]
+				}
+			}
+
+			s, err := ParseWith(reader, keyNode, "foo."+tc.path, nil)
+			assert.NoError(tt, err)
+			tc.validate(tt, s)
+		})
+	}
+}
+
+const BITNAMI_VALUES = `
+## @section Image parameters
+## @param image.registry Image registry
+## @param image.tag [string, nullable, default: appVersion] Image tag
+## @skip image.digest
+## @extra image.pullSecrets [array] Image pull secrets
+
+## Image settings
+## ref: https://kubernetes.io/docs/concepts/containers/images
+image:
+  registry: docker.io
+  tag: ""
+  digest: ""
+  # type: string
+  # ---
+  # overridden by the annotation
+  ## @param image.pullPolicy Image pull policy
+  pullPolicy: IfNotPresent
+  # Undocumented by readme-generator
+  native: value
+`
+
+func TestBitnamiDialect(t *testing.T) {
+	var tests = []struct {
+		name     string
+		path     string
+		validate func(tt *testing.T, s *pkg.JsonSchema)
+	}{
+		{
+			name: "param matched by key path",
+			path: "registry",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "Image registry", s.Description)
+				assert.Equal(tt, "Image parameters", s.DocsSection)
+			},
+		},
+		{
+			name: "param modifiers",
+			path: "tag",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "Image tag", s.Description)
+				assert.Equal(tt, pkg.NewSchemaType("string", "null"), s.Type)
+				assert.Equal(tt, "appVersion", s.DocsDefault)
+			},
+		},
+		{
+			name: "skipped key",
+			path: "digest",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.True(tt, s.DocsIgnored)
+			},
+		},
+		{
+			name: "param takes precedence over native keywords",
+			path: "pullPolicy",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "Image pull policy", s.Description)
+				assert.Equal(tt, pkg.NewSchemaType("string"), s.Type)
+			},
+		},
+		{
+			name: "native comments are still read",
+			path: "native",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, "Undocumented by readme-generator", s.Description)
+				assert.Equal(tt, "", s.DocsSection)
+			},
+		},
+	}
+
+	yamlNode := &yaml.Node{}
+	err := yaml.Unmarshal([]byte(BITNAMI_VALUES), yamlNode)
+	assert.NoError(t, err)
+
+	reader := NewBitnamiReader(yamlNode, NativeReader{})
+	assert.Equal(t, []string{"image.pullSecrets"}, lo.Map(reader.ExtraValues(), func(e ExtraValue, _ int) string { return e.Path }))
+
+	image := yamlNode.Content[0].Content[1]
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			var keyNode *yaml.Node
+			for i := 0; i+1 < len(image.Content); i += 2 {
+				if image.Content[i].Value == tc.path {
+					keyNode = image.Content[i]
+				}
+			}
+
+			s, err := ParseWith(reader, keyNode, "image."+tc.path, nil)
+			assert.NoError(tt, err)
+			tc.validate(tt, s)
+		})
+	}
+}
+
+const SCHEMA_FENCES = `
+image:
+  # @schema
+  # type: string
+  # required: true
+  # @schema
+  # -- The image tag
+  tag: ""
+  # Image pull policy
+  # @schema
+  # enum: [Always, IfNotPresent]
+  # @schema
+  pullPolicy: IfNotPresent
+  # @schema
+  # type: string
+  # @schema
+  # -- Image digest
+  # @default -- the tag's digest
+  digest: ""
+  # @schema
+  # type: string
+  unclosed: ""
+# Not fenced
+replicas: 1
+`
+
+func TestSchemaFences(t *testing.T) {
+	var tests = []struct {
+		name          string
+		dialect       Dialect
+		key           string
+		expectedError string
+		validate      func(tt *testing.T, s *pkg.JsonSchema)
+	}{
+		{
+			name: "fenced keywords with description after",
+			key:  "tag",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, pkg.NewSchemaType("string"), s.Type)
+				assert.True(tt, s.RequiredValue)
+				assert.Equal(tt, "The image tag", s.Description)
+			},
+		},
+		{
+			name: "fenced keywords with description before",
+			key:  "pullPolicy",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, []any{"Always", "IfNotPresent"}, s.Enum)
+				assert.Equal(tt, "Image pull policy", s.Description)
+			},
+		},
+		{
+			name:    "fenced keywords with helm-docs annotations",
+			dialect: DialectHelmDocs,
+			key:     "digest",
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				assert.Equal(tt, pkg.NewSchemaType("string"), s.Type)
+				assert.Equal(tt, "Image digest", s.Description)
+				assert.Equal(tt, "the tag's digest", s.DocsDefault)
+			},
+		},
+		{
+			name:          "errors when the fence isn't closed",
+			key:           "unclosed",
+			expectedError: "@schema block is not closed",
+		},
+	}
+
+	yamlNode := &yaml.Node{}
+	err := yaml.Unmarshal([]byte(SCHEMA_FENCES), yamlNode)
+	assert.NoError(t, err)
+	image := yamlNode.Content[0].Content[1]
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			var keyNode *yaml.Node
+			for i := 0; i+1 < len(image.Content); i += 2 {
+				if image.Content[i].Value == tc.key {
+					keyNode = image.Content[i]
+				}
+			}
+
+			reader := NewCommentReader(tc.dialect, yamlNode, "")
+			s, err := ParseWith(reader, keyNode, "image."+tc.key, nil)
+			if tc.expectedError != "" {
+				assert.ErrorContains(tt, err, tc.expectedError)
+				return
+			}
+			assert.NoError(tt, err)
+			tc.validate(tt, s)
+		})
+	}
+}
+
+func TestRewriteSchemaFences(t *testing.T) {
+	document := strings.Replace(SCHEMA_FENCES, "  # type: string\n  unclosed", "  # type: string\n  # @schema\n  unclosed", 1)
+
+	rewritten, count, err := RewriteSchemaFences(document)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+	assert.NotContains(t, rewritten, "@schema")
+	assert.Contains(t, rewritten, "  # type: string\n  # required: true\n  # ---\n  # The image tag\n  tag: \"\"")
+	assert.Contains(t, rewritten, "# Not fenced\nreplicas: 1\n")
+
+	// the rewritten comments are read the same way as the fenced comments
+	original := &yaml.Node{}
+	assert.NoError(t, yaml.Unmarshal([]byte(document), original))
+	native := &yaml.Node{}
+	assert.NoError(t, yaml.Unmarshal([]byte(rewritten), native))
+
+	for _, key := range []string{"tag", "pullPolicy", "unclosed"} {
+		originalKey, nativeKey := mappingKey(original, key), mappingKey(native, key)
+		expected, err := Parse(originalKey, nil)
+		assert.NoError(t, err)
+		actual, err := Parse(nativeKey, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual, key)
+	}
+
+	_, _, err = RewriteSchemaFences(SCHEMA_FENCES)
+	assert.ErrorContains(t, err, "line 20: @schema block is not closed")
+}
+
+func mappingKey(document *yaml.Node, key string) *yaml.Node {
+	image := document.Content[0].Content[1]
+	for i := 0; i+1 < len(image.Content); i += 2 {
+		if image.Content[i].Value == key {
+			return image.Content[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package comments
+
+import (
+	"fmt"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Dialect is the syntax doc comments are written in.
+type Dialect string
+
+const (
+	DialectNative   Dialect = "native"
+	DialectHelmDocs Dialect = "helm-docs"
+	DialectBitnami  Dialect = "bitnami"
+)
+
+func NewDialect(dialectStr string) (Dialect, error) {
+	switch strings.ToLower(dialectStr) {
+	case "native":
+		return DialectNative, nil
+	case "helm-docs":
+		return DialectHelmDocs, nil
+	case "bitnami":
+		return DialectBitnami, nil
+	default:
+		return "", fmt.Errorf("invalid comment dialect: %s", dialectStr)
+	}
+}
+
+// CommentReader reads the schema keywords from the comment of a node, as
+// key/value node pairs. The path is the dotted key path of the node's value.
+type CommentReader interface {
+	CommentNodes(node *yaml.Node, path string) ([]*yaml.Node, error)
+}
+
+// NewCommentReader returns the reader for the dialect. Some dialects describe
+// values from comments anywhere in the document, which is read up front. The
+// detached marker is used for native comments, see NativeReader.
+func NewCommentReader(dialect Dialect, document *yaml.Node, detachedMarker string) CommentReader {
+	native := NativeReader{DetachedMarker: detachedMarker}
+	switch dialect {
+	case DialectHelmDocs:
+		return NewHelmDocsReader(document, native)
+	case DialectBitnami:
+		return NewBitnamiReader(document, native)
+	default:
+		return native
+	}
+}
+
+// ExtraValue is a value documented by a comment but absent from the values
+// file.
+type ExtraValue struct {
+	// Path is the dotted key path of the value
+	Path string
+	// Line is the line of the node whose comment documents the value
+	Line int
+}
+
+// ExtraValuesReader is implemented by the readers of dialects that can
+// document values absent from the values file. The comments of those values
+// are read from the reader with their key path.
+type ExtraValuesReader interface {
+	ExtraValues() []ExtraValue
+}
+
+// NativeReader reads comments written in this project's syntax.
+//
+// The doc comment is made of the comment paragraphs above the key, up to the
+// first paragraph starting with the detached marker (DefaultDetachedMarker
+// when empty). Paragraphs from there up, like section banners and license
+// headers, are detached from the key.
+type NativeReader struct {
+	DetachedMarker string
+}
+
+func (r NativeReader) CommentNodes(node *yaml.Node, path string) ([]*yaml.Node, error) {
+	commentNodes := []*yaml.Node{}
+	if node.HeadComment == "" {
+		return commentNodes, nil
+	}
+
+	marker := r.DetachedMarker
+	if marker == "" {
+		marker = DefaultDetachedMarker
+	}
+	lines := docCommentLines(node.HeadComment, marker)
+
+	if nodes, ok, err := schemaFenceNodes(node, lines); ok || err != nil {
+		return nodes, err
+	}
+
+	commentDocs, err := parseNodeComment(node, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	commentDocs, required := cutMarker(commentDocs, RequiredMarker)
+	if required {
+		commentNodes = append(commentNodes, KeyValueNodes("required", "true")...)
+	}
+
+	// the description paragraphs of every document make up one description
+	description := []string{}
+	for _, commentDoc := range commentDocs {
+		nodes, paragraphs := commentDocNodes(commentDoc)
+		commentNodes = append(commentNodes, nodes...)
+		description = append(description, paragraphs...)
+	}
+	if len(description) > 0 {
+		commentNodes = append(commentNodes, stringNodes("description", strings.Join(description, "\n\n"))...)
+	}
+
+	return commentNodes, nil
+}
+
+// commentDocNodes reads a yaml document of a doc comment as either a map of
+// schema keywords or description paragraphs. Documents that are neither are
+// read by paragraph, since keywords may be followed by description paragraphs
+// without a document separator.
+func commentDocNodes(commentDoc string) ([]*yaml.Node, []string) {
+	if nodes, ok := commentAsDescriptionNodes(commentDoc); ok {
+		return nil, []string{nodes[1].Value}
+	}
+	if nodes, ok := commentAsMapNodes(commentDoc); ok {
+		return nodes, nil
+	}
+
+	nodes := []*yaml.Node{}
+	description := []string{}
+	for _, paragraph := range strings.Split(commentDoc, "\n\n") {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		if _, ok := commentAsDescriptionNodes(paragraph); !ok {
+			if keywordNodes, ok := commentAsMapNodes(paragraph); ok {
+				nodes = append(nodes, keywordNodes...)
+				continue
+			}
+		}
+		description = append(description, strings.TrimSpace(paragraph))
+	}
+	return nodes, description
+}
@@ -66,6 +66,10 @@ func (e *CommentError) Error() string {
 	return e.Err.Error()
 }
 
+func (e *CommentError) Unwrap() error {
+	return e.Err
+}
+
 type DisplayLine struct {
 	LineNum int
 	Content string
@@ -0,0 +1,153 @@
+package comments
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+var (
+	schemaFence            = regexp.MustCompile(`^\s*#\s*@schema\s*$`)
+	schemaFenceDescription = regexp.MustCompile(`^(\s*#\s*)--\s?`)
+)
+
+// schemaFenceNodes reads a comment written for helm-schema, where the schema
+// keywords are fenced by @schema lines:
+//
+//	# @schema
+//	# type: string
+//	# required: true
+//	# @schema
+//	# -- description of the value
+//
+// The doc comment lines outside of the fences are the description. It reports
+// false when the comment has no fences.
+func schemaFenceNodes(node *yaml.Node, lines []string) ([]*yaml.Node, bool, error) {
+	schemaLines, descriptionLines, ok, err := splitSchemaFences(lines)
+	if err != nil {
+		return nil, true, NewCommentError(node, err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	commentNodes, err := schemaBlockNodes(schemaLines)
+	if err != nil {
+		return nil, true, NewCommentError(node, err)
+	}
+
+	descriptionLines = withoutDescriptionMarkers(descriptionLines)
+	description := strings.TrimSpace(strings.Join(uncommentLines(descriptionLines), "\n"))
+	if description != "" && !hasKey(commentNodes, "description") {
+		commentNodes = append(commentNodes, stringNodes("description", description)...)
+	}
+
+	return commentNodes, true, nil
+}
+
+// schemaBlockNodes converts the comment lines within @schema fences to schema
+// keyword nodes.
+func schemaBlockNodes(lines []string) ([]*yaml.Node, error) {
+	schemaDoc := strings.Join(uncommentLines(lines), "\n")
+	if strings.TrimSpace(schemaDoc) == "" {
+		return []*yaml.Node{}, nil
+	}
+
+	nodes, ok := commentAsMapNodes(schemaDoc)
+	if !ok {
+		return nil, fmt.Errorf("@schema block is not a map of schema keywords")
+	}
+	return nodes, nil
+}
+
+// splitSchemaFences separates the lines within @schema fences from the rest
+// of the comment lines, leaving out the fences themselves.
+func splitSchemaFences(lines []string) ([]string, []string, bool, error) {
+	schemaLines := []string{}
+	descriptionLines := []string{}
+	found := false
+	inFence := false
+	for _, line := range lines {
+		if schemaFence.MatchString(line) {
+			found = true
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			schemaLines = append(schemaLines, line)
+			continue
+		}
+		descriptionLines = append(descriptionLines, line)
+	}
+
+	if inFence {
+		return nil, nil, true, fmt.Errorf("@schema block is not closed")
+	}
+	return schemaLines, descriptionLines, found, nil
+}
+
+// withoutDescriptionMarkers removes the -- prefix helm-docs descriptions start
+// with, keeping the comment prefix of each line.
+func withoutDescriptionMarkers(lines []string) []string {
+	cleaned := make([]string, len(lines))
+	for i, line := range lines {
+		cleaned[i] = schemaFenceDescription.ReplaceAllString(line, "$1")
+	}
+	return cleaned
+}
+
+// uncommentLines removes the comment prefix of each line.
+func uncommentLines(lines []string) []string {
+	uncommented := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "#")
+		uncommented[i] = strings.TrimPrefix(line, " ")
+	}
+	return uncommented
+}
+
+// RewriteSchemaFences rewrites the helm-schema comments in the values file
+// content to native comments, with the schema keywords followed by the
+// description in a second document. Other comments are left as they are. It
+// returns the rewritten content and the number of comments rewritten.
+func RewriteSchemaFences(content string) (string, int, error) {
+	lines := strings.Split(content, "\n")
+	rewritten := []string{}
+	count := 0
+
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(strings.TrimSpace(lines[i]), "#") {
+			rewritten = append(rewritten, lines[i])
+			continue
+		}
+
+		// collect the block of consecutive comment lines
+		start := i
+		for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "#") {
+			i++
+		}
+		block := lines[start : i+1]
+
+		schemaLines, descriptionLines, ok, err := splitSchemaFences(block)
+		if err != nil {
+			return "", 0, fmt.Errorf("line %d: %w", start+1, err)
+		}
+		if !ok {
+			rewritten = append(rewritten, block...)
+			continue
+		}
+
+		indent := block[0][:len(block[0])-len(strings.TrimLeft(block[0], " \t"))]
+		rewritten = append(rewritten, schemaLines...)
+		if len(schemaLines) > 0 && len(descriptionLines) > 0 {
+			rewritten = append(rewritten, indent+"# ---")
+		}
+		rewritten = append(rewritten, withoutDescriptionMarkers(descriptionLines)...)
+		count++
+	}
+
+	return strings.Join(rewritten, "\n"), count, nil
+}
@@ -0,0 +1,213 @@
+package comments
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+var (
+	helmDocsDescription = regexp.MustCompile(`^#\s*--\s?(.*)$`)
+	helmDocsPath        = regexp.MustCompile(`^#\s+([^\s@#-][^\s]*)\s+--\s?(.*)$`)
+	helmDocsDefault     = regexp.MustCompile(`^#\s+@default\s+--\s?(.*)$`)
+	helmDocsSection     = regexp.MustCompile(`^#\s+@section\s+--\s?(.*)$`)
+	helmDocsAnnotation  = regexp.MustCompile(`^#\s+@(\w+)`)
+)
+
+// HelmDocsReader reads comments written for helm-docs:
+//
+//	# -- description of the value
+//	# @default -- text shown as the default in docs
+//	# @section -- docs section of the value
+//	# @raw      (keeps the description's line breaks)
+//	# @ignored  (leaves the value out of the docs)
+//	# foo.bar -- description of the value at a key path, placed anywhere
+//
+// Comments without helm-docs annotations are read as native comments, so
+// charts can be migrated one comment at a time.
+type HelmDocsReader struct {
+	// paths holds the comment lines of key path comments by key path
+	paths  map[string][]string
+	native NativeReader
+}
+
+func NewHelmDocsReader(document *yaml.Node, native NativeReader) *HelmDocsReader {
+	r := &HelmDocsReader{paths: map[string][]string{}, native: native}
+	r.collectPathComments(document)
+	return r
+}
+
+func (r *HelmDocsReader) CommentNodes(node *yaml.Node, path string) ([]*yaml.Node, error) {
+	lines, _ := splitPathComments(strings.Split(node.HeadComment, "\n"))
+
+	// like helm-docs, only the paragraph directly above the key is read
+	paragraph := lines
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" {
+			paragraph = lines[i+1:]
+			break
+		}
+	}
+
+	// helm-schema keywords can be fenced above or below the helm-docs lines
+	schemaLines, paragraph, hasFences, err := splitSchemaFences(paragraph)
+	if err != nil {
+		return nil, NewCommentError(node, err)
+	}
+
+	var commentNodes []*yaml.Node
+	if start := slices.IndexFunc(paragraph, isHelmDocsLine); start >= 0 {
+		commentNodes = helmDocsNodes(paragraph[start:])
+		if hasFences {
+			schemaNodes, err := schemaBlockNodes(schemaLines)
+			if err != nil {
+				return nil, NewCommentError(node, err)
+			}
+			commentNodes = append(withoutKeys(commentNodes, schemaNodes), schemaNodes...)
+		}
+	} else {
+		// the comment is re-read without the key path comments it contained
+		nativeNode := *node
+		nativeNode.HeadComment = strings.Join(lines, "\n")
+		nodes, err := r.native.CommentNodes(&nativeNode, path)
+		if err != nil {
+			return nil, err
+		}
+		commentNodes = nodes
+	}
+
+	// key path comments apply to values without a description of their own
+	if pathLines, ok := r.paths[path]; ok && !hasKey(commentNodes, "description") {
+		commentNodes = append(commentNodes, withoutKeys(helmDocsNodes(pathLines), commentNodes)...)
+	}
+
+	return commentNodes, nil
+}
+
+// collectPathComments reads the key path comments from every comment in the
+// document.
+func (r *HelmDocsReader) collectPathComments(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+
+	for _, comment := range []string{node.HeadComment, node.LineComment, node.FootComment} {
+		if comment == "" {
+			continue
+		}
+		_, blocks := splitPathComments(strings.Split(comment, "\n"))
+		for path, lines := range blocks {
+			r.paths[path] = lines
+		}
+	}
+
+	for _, child := range node.Content {
+		r.collectPathComments(child)
+	}
+}
+
+// splitPathComments separates the key path comment blocks from the comment
+// lines. Each block is returned with its first line rewritten as a plain
+// description line.
+func splitPathComments(lines []string) ([]string, map[string][]string) {
+	remaining := []string{}
+	blocks := map[string][]string{}
+
+	for i := 0; i < len(lines); i++ {
+		match := helmDocsPath.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if match == nil {
+			remaining = append(remaining, lines[i])
+			continue
+		}
+
+		block := []string{"# -- " + match[2]}
+		for i+1 < len(lines) && isContinuationLine(lines[i+1]) {
+			i++
+			block = append(block, lines[i])
+		}
+		blocks[match[1]] = block
+	}
+
+	return remaining, blocks
+}
+
+// helmDocsNodes converts the comment lines, starting at the description line,
+// to schema keyword nodes.
+func helmDocsNodes(lines []string) []*yaml.Node {
+	description := []string{}
+	raw := false
+	nodes := []*yaml.Node{}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if match := helmDocsDefault.FindStringSubmatch(line); match != nil {
+			nodes = append(nodes, stringNodes("docsDefault", match[1])...)
+			continue
+		}
+		if match := helmDocsSection.FindStringSubmatch(line); match != nil {
+			nodes = append(nodes, stringNodes("docsSection", match[1])...)
+			continue
+		}
+		if match := helmDocsAnnotation.FindStringSubmatch(line); match != nil {
+			switch match[1] {
+			case "raw":
+				raw = true
+			case "ignored":
+				nodes = append(nodes, KeyValueNodes("docsIgnored", "true")...)
+			}
+			continue
+		}
+		if match := helmDocsDescription.FindStringSubmatch(line); match != nil {
+			description = append(description, match[1])
+			continue
+		}
+
+		text, _ := strings.CutPrefix(line, "#")
+		description = append(description, strings.TrimPrefix(text, " "))
+	}
+
+	separator := " "
+	if raw {
+		separator = "\n"
+	}
+	if text := strings.TrimSpace(strings.Join(description, separator)); text != "" {
+		nodes = append(stringNodes("description", text), nodes...)
+	}
+
+	return nodes
+}
+
+func isHelmDocsLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return helmDocsDescription.MatchString(line) ||
+		helmDocsDefault.MatchString(line) ||
+		helmDocsSection.MatchString(line) ||
+		helmDocsAnnotation.MatchString(line)
+}
+
+// isContinuationLine reports whether the line continues a description.
+func isContinuationLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "#") &&
+		!helmDocsPath.MatchString(line) &&
+		!helmDocsDescription.MatchString(line)
+}
+
+// stringNodes returns a key/value pair whose value is always read as a string.
+func stringNodes(key string, value string) []*yaml.Node {
+	nodes := KeyValueNodes(key, value)
+	nodes[1].Tag = "!!str"
+	return nodes
+}
+
+func hasKey(nodes []*yaml.Node, key string) bool {
+	for i := 0; i+1 < len(nodes); i += 2 {
+		if nodes[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package comments
+
+import (
+	"errors"
+	"helmvalues/pkg"
+	"reflect"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// ErrUnknownKeyword is returned by Parse when a doc comment uses a keyword
+// that isn't part of the schema, which would otherwise be silently dropped.
+var ErrUnknownKeyword = errors.New("unknown schema keyword")
+
+// keywords holds the keywords accepted in doc comments, which are the yaml
+// names of the schema fields.
+var keywords = schemaKeywords()
+
+func schemaKeywords() map[string]bool {
+	result := map[string]bool{}
+
+	t := reflect.TypeOf(pkg.JsonSchema{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			result[name] = true
+		}
+	}
+
+	return result
+}
+
+// unknownKeywords returns the keys of the key/value pairs in nodes which
+// aren't schema keywords or extension keywords.
+func unknownKeywords(nodes []*yaml.Node) []string {
+	unknown := []string{}
+	for i := 0; i+1 < len(nodes); i += 2 {
+		if !keywords[nodes[i].Value] && !strings.HasPrefix(nodes[i].Value, pkg.ExtensionPrefix) {
+			unknown = append(unknown, nodes[i].Value)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,47 @@
+package comments
+
+import (
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// RequiredMarker is a comment line marking the value as required by its parent.
+const RequiredMarker = "@required"
+
+// cutMarker removes the marker lines from the comment docs, reporting whether
+// any were found.
+func cutMarker(docs []string, marker string) ([]string, bool) {
+	found := false
+	result := make([]string, len(docs))
+	for i, doc := range docs {
+		lines := []string{}
+		for _, line := range strings.Split(doc, "\n") {
+			if strings.TrimSpace(line) == marker {
+				found = true
+				continue
+			}
+			lines = append(lines, line)
+		}
+		result[i] = strings.Join(lines, "\n")
+	}
+	return result, found
+}
+
+// cutRequiredValue removes the boolean required keywords from the key/value
+// pairs. A boolean marks the value as required by its parent, unlike the
+// required keyword of an object which lists its required properties.
+func cutRequiredValue(nodes []*yaml.Node) ([]*yaml.Node, bool, bool) {
+	result := []*yaml.Node{}
+	required, found := false, false
+	for i := 0; i+1 < len(nodes); i += 2 {
+		var value bool
+		if nodes[i].Value == "required" && nodes[i+1].ShortTag() == "!!bool" && nodes[i+1].Decode(&value) == nil {
+			required = required || value
+			found = true
+			continue
+		}
+		result = append(result, nodes[i], nodes[i+1])
+	}
+	return result, required, found
+}
@@ -43,6 +43,13 @@ func commentAsMapNodes(comment string) ([]*yaml.Node, bool) {
 		return []*yaml.Node{}, false
 	}
 
+	// keywords are always scalars, eg: not a markdown link reference like [1]: ...
+	for i := 0; i < len(node.Content[0].Content); i += 2 {
+		if node.Content[0].Content[i].Kind != yaml.ScalarNode {
+			return []*yaml.Node{}, false
+		}
+	}
+
 	return node.Content[0].Content, true
 }
 
@@ -58,6 +65,16 @@ func KeyValueNodes(key string, value string) []*yaml.Node {
 	return []*yaml.Node{keyNode, valueNode}
 }
 
+// KeyNodes pairs a scalar key with an existing value node, retaining the
+// value's tag and style so it decodes to the same type it had in the values file.
+func KeyNodes(key string, value *yaml.Node) []*yaml.Node {
+	keyNode := &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Value: key,
+	}
+	return []*yaml.Node{keyNode, value}
+}
+
 func newDocumentNode(content ...*yaml.Node) *yaml.Node {
 	return &yaml.Node{
 		Kind:    yaml.DocumentNode,
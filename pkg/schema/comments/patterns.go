@@ -0,0 +1,144 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"helmvalues/pkg"
+	"regexp/syntax"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// ErrIncompatiblePattern is returned by Parse when a pattern uses regular
+// expression syntax that isn't part of ECMA-262, the dialect json schema
+// patterns are written in.
+var ErrIncompatiblePattern = errors.New("pattern is not ECMA-262 compatible")
+
+// ErrInvalidPattern is returned by Parse when a pattern isn't a valid regular
+// expression.
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// validatePatterns checks the pattern and patternProperties keywords of the
+// schema and its subschemas.
+func validatePatterns(node *yaml.Node, s *pkg.JsonSchema) error {
+	var err error
+	s.Walk(func(sub *pkg.JsonSchema) {
+		patterns := []string{}
+		if sub.Pattern != "" {
+			patterns = append(patterns, sub.Pattern)
+		}
+		if sub.PatternProperties != nil {
+			for pattern := range sub.PatternProperties.Keys() {
+				patterns = append(patterns, pattern)
+			}
+		}
+
+		for _, pattern := range patterns {
+			if err != nil {
+				return
+			}
+			if reason := ecmaIncompatibility(pattern); reason != "" {
+				err = fmt.Errorf(
+					"line %d: %w: %s: %s",
+					commentLine(node, pattern), ErrIncompatiblePattern, pattern, reason,
+				)
+			} else if reason := malformedPattern(pattern); reason != "" {
+				err = fmt.Errorf(
+					"line %d: %w: %s: %s",
+					commentLine(node, pattern), ErrInvalidPattern, pattern, reason,
+				)
+			}
+		}
+	})
+	return err
+}
+
+// ecmaIncompatibility returns why the pattern isn't ECMA-262 compatible, or
+// an empty string if it is. Only constructs that are accepted by Go's regexp
+// package but mean something else (or nothing) in ECMA-262 are reported.
+func ecmaIncompatibility(pattern string) string {
+	runes := []rune(pattern)
+	inClass := false
+
+	for i := 0; i < len(runes); i++ {
+		next := func(offset int) rune {
+			if i+offset < len(runes) {
+				return runes[i+offset]
+			}
+			return 0
+		}
+
+		switch {
+		case runes[i] == '\\':
+			switch next(1) {
+			case 'A', 'z':
+				return fmt.Sprintf(`\%c anchors are not supported, use ^ or $`, next(1))
+			case 'Q':
+				return `\Q...\E literal quoting is not supported`
+			case 'C':
+				return `\C is not supported`
+			case 'p', 'P':
+				if next(2) != '{' {
+					return fmt.Sprintf(`unicode classes must be written \%c{...}`, next(1))
+				}
+			}
+			// skip the escaped character
+			i++
+		case inClass && runes[i] == '[' && next(1) == ':':
+			return "POSIX character classes like [[:alpha:]] are not supported"
+		case inClass && runes[i] == ']':
+			inClass = false
+		case !inClass && runes[i] == '[':
+			// a leading ] is a literal in Go's classes, but closes the class in ECMA-262
+			if next(1) == ']' {
+				return `[] is an empty class, write a literal ] as \]`
+			}
+			if next(1) == '^' && next(2) == ']' {
+				return `[^] matches any character, write a literal ] as \]`
+			}
+			inClass = true
+		case !inClass && runes[i] == '(' && next(1) == '?':
+			switch next(2) {
+			case 'P':
+				return "named groups must be written (?<name>...)"
+			case 'i', 'm', 's', 'U', '-':
+				return "inline flags like (?i) are not supported"
+			}
+		}
+	}
+
+	return ""
+}
+
+// malformedPattern returns why the pattern isn't a valid regular expression,
+// or an empty string if it is. Go's syntax is close to a subset of ECMA-262,
+// so only errors which are also errors in ECMA-262 are reported, while syntax
+// Go doesn't support (eg: lookarounds and backreferences) is accepted.
+func malformedPattern(pattern string) string {
+	_, err := syntax.Parse(pattern, syntax.Perl)
+	var syntaxErr *syntax.Error
+	if !errors.As(err, &syntaxErr) {
+		return ""
+	}
+
+	switch syntaxErr.Code {
+	case syntax.ErrMissingBracket, syntax.ErrMissingParen, syntax.ErrUnexpectedParen,
+		syntax.ErrMissingRepeatArgument, syntax.ErrTrailingBackslash, syntax.ErrInvalidCharRange:
+		return syntaxErr.Code.String()
+	default:
+		return ""
+	}
+}
+
+// commentLine returns the line of the node's comment which contains the text,
+// or the line of the node itself.
+func commentLine(node *yaml.Node, text string) int {
+	lines := strings.Split(node.HeadComment, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, text) {
+			return node.Line - len(lines) + i
+		}
+	}
+	return node.Line
+}
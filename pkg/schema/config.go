@@ -1,11 +1,80 @@
 package schema
 
-import "github.com/sirupsen/logrus"
+import (
+	"fmt"
+	"helmvalues/pkg/schema/comments"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+)
 
 type Config struct {
-	StdOut        bool
-	Strict        bool
-	DryRun        bool
-	WriteModeline bool
-	LogLevel      logrus.Level
+	StdOut               bool
+	Strict               bool
+	DryRun               bool
+	Check                bool
+	Diff                 bool
+	Color                bool
+	WriteModeline        bool
+	AnchorDefinitions    bool
+	TagSchemas           map[string]*yaml.Node
+	BundleRefs           bool
+	RefCacheDir          string
+	ComposeSubcharts     bool
+	AdditionalProperties AdditionalPropertiesPolicy
+	CommentDialect       comments.Dialect
+	FootComments         bool
+	DetachedMarker       string
+	LogLevel             logrus.Level
+}
+
+// AdditionalPropertiesPolicy decides when generated object schemas disallow
+// keys that aren't declared in the values file.
+type AdditionalPropertiesPolicy string
+
+const (
+	// AdditionalPropertiesStrict disallows undeclared keys in every object
+	AdditionalPropertiesStrict AdditionalPropertiesPolicy = "strict"
+	// AdditionalPropertiesLax leaves additionalProperties unset, allowing any keys
+	AdditionalPropertiesLax AdditionalPropertiesPolicy = "lax"
+	// AdditionalPropertiesStrictExceptEmpty disallows undeclared keys, except
+	// in empty objects which are assumed to be free-form
+	AdditionalPropertiesStrictExceptEmpty AdditionalPropertiesPolicy = "strict-except-empty"
+)
+
+func NewAdditionalPropertiesPolicy(policyStr string) (AdditionalPropertiesPolicy, error) {
+	switch strings.ToLower(policyStr) {
+	case "strict":
+		return AdditionalPropertiesStrict, nil
+	case "lax":
+		return AdditionalPropertiesLax, nil
+	case "strict-except-empty":
+		return AdditionalPropertiesStrictExceptEmpty, nil
+	default:
+		return "", fmt.Errorf("invalid additional properties policy: %s", policyStr)
+	}
+}
+
+// Source decides where the schema of a chart is read from, for commands which
+// use the schema rather than write it.
+type Source string
+
+const (
+	// SourceFile reads the chart's values.schema.json
+	SourceFile Source = "file"
+	// SourceGenerate generates the schema from the chart's values file, as the
+	// schema command would
+	SourceGenerate Source = "generate"
+)
+
+func NewSource(sourceStr string) (Source, error) {
+	switch strings.ToLower(sourceStr) {
+	case "file":
+		return SourceFile, nil
+	case "generate":
+		return SourceGenerate, nil
+	default:
+		return "", fmt.Errorf("invalid schema source: %s", sourceStr)
+	}
 }
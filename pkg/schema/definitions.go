@@ -0,0 +1,83 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"helmvalues/pkg"
+	"io/fs"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// readDefinitionsFile reads the shared definitions declared in the chart's
+// definitions file. The file maps definition names to schemas, and is optional.
+func readDefinitionsFile(path string) (*pkg.EncodableOrderedMap[string, *pkg.JsonSchema], error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema](), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+	if err := yaml.Unmarshal(content, definitions); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return definitions, nil
+}
+
+// defineSchema moves the schema declaring a definition name into the shared
+// definitions, returning a schema referencing it in its place. The title,
+// description, default and required marker stay with the reference since they
+// describe the value rather than the definition.
+func (g *Generator) defineSchema(s *pkg.JsonSchema) (*pkg.JsonSchema, error) {
+	name := s.Define
+	if _, ok := g.definitions.Get(name); ok {
+		return nil, fmt.Errorf("definition %s is already declared", name)
+	}
+
+	definition := *s
+	definition.Define = ""
+	definition.Title = ""
+	definition.Description = ""
+	definition.Default = nil
+	definition.RequiredValue = false
+	g.definitions.Set(name, &definition)
+
+	return &pkg.JsonSchema{
+		Ref:           definitionRef(name),
+		Title:         s.Title,
+		Description:   s.Description,
+		Default:       s.Default,
+		RequiredValue: s.RequiredValue,
+	}, nil
+}
+
+// checkLocalRefs reports references to definitions that don't exist in the
+// root schema.
+func (g *Generator) checkLocalRefs(root *pkg.JsonSchema) error {
+	missing := []string{}
+	root.Walk(func(s *pkg.JsonSchema) {
+		if !strings.HasPrefix(s.Ref, "#/definitions/") && !strings.HasPrefix(s.Ref, "#/$defs/") {
+			return
+		}
+		if _, ok := root.ResolveLocalRef(s.Ref); !ok {
+			missing = append(missing, s.Ref)
+		}
+	})
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	err := fmt.Errorf("unresolved definition references: %s", strings.Join(missing, ", "))
+	if g.plan.StrictComments() {
+		return err
+	}
+	g.logger.Warn(err.Error())
+	return nil
+}
@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"helmvalues/pkg"
+	"strings"
+)
+
+// isDraft4 reports whether the $schema targets draft-04 or earlier, where
+// exclusiveMinimum and exclusiveMaximum are booleans.
+func isDraft4(schemaURI string) bool {
+	return strings.Contains(schemaURI, "draft-04") || strings.Contains(schemaURI, "draft-03")
+}
+
+// normalizeExclusiveLimits rewrites exclusiveMinimum and exclusiveMaximum to
+// the form used by the draft the root schema targets, so either form can be
+// written in doc comments.
+func (g *Generator) normalizeExclusiveLimits(root *pkg.JsonSchema) {
+	draft4 := isDraft4(root.Schema)
+	root.Walk(func(s *pkg.JsonSchema) {
+		if draft4 {
+			s.Minimum, s.ExclusiveMinimum = draft4Limit(s.Minimum, s.ExclusiveMinimum)
+			s.Maximum, s.ExclusiveMaximum = draft4Limit(s.Maximum, s.ExclusiveMaximum)
+			return
+		}
+
+		var ok bool
+		if s.Minimum, s.ExclusiveMinimum, ok = draft6Limit(s.Minimum, s.ExclusiveMinimum); !ok {
+			g.logger.Warnf("schema: %s: exclusiveMinimum: true has no minimum, ignoring it", g.plan.chart.Details.Name)
+		}
+		if s.Maximum, s.ExclusiveMaximum, ok = draft6Limit(s.Maximum, s.ExclusiveMaximum); !ok {
+			g.logger.Warnf("schema: %s: exclusiveMaximum: true has no maximum, ignoring it", g.plan.chart.Details.Name)
+		}
+	})
+}
+
+// draft4Limit converts a numeric exclusive limit to a limit with the boolean
+// exclusive modifier.
+func draft4Limit(limit pkg.Number, exclusive pkg.ExclusiveLimit) (pkg.Number, pkg.ExclusiveLimit) {
+	if number, ok := exclusive.Number(); ok {
+		return number, pkg.NewExclusiveLimit(true)
+	}
+	return limit, exclusive
+}
+
+// draft6Limit converts a boolean exclusive modifier to a numeric exclusive
+// limit. It reports false when the modifier has no limit to apply to.
+func draft6Limit(limit pkg.Number, exclusive pkg.ExclusiveLimit) (pkg.Number, pkg.ExclusiveLimit, bool) {
+	isExclusive, ok := exclusive.Bool()
+	switch {
+	case !ok:
+		return limit, exclusive, true
+	case !isExclusive:
+		return limit, "", true
+	case limit == "":
+		return limit, "", false
+	default:
+		return "", pkg.ExclusiveLimit(limit), true
+	}
+}
@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"helmvalues/pkg"
+	"helmvalues/pkg/schema/comments"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// addExtraValues declares the values that the comment dialect documents but
+// the values file doesn't set. Missing parent objects are declared along the
+// way, while values present in the values file are left as generated.
+func (g *Generator) addExtraValues(root *pkg.JsonSchema) error {
+	reader, ok := g.comments.(comments.ExtraValuesReader)
+	if !ok {
+		return nil
+	}
+
+	for _, extra := range reader.ExtraValues() {
+		keys := strings.Split(extra.Path, ".")
+
+		parent := root
+		for _, key := range keys[:len(keys)-1] {
+			if parent.Properties == nil {
+				parent.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+			}
+			child, ok := parent.Properties.Get(key)
+			if !ok {
+				child = &pkg.JsonSchema{Type: pkg.NewSchemaType("object"), Title: key}
+				parent.Properties.Set(key, child)
+			}
+			parent = child
+		}
+
+		key := keys[len(keys)-1]
+		if parent.Properties != nil && parent.Properties.Has(key) {
+			g.logger.Debugf("schema: %s: extra value is set in the values file: %s", g.plan.chart.Details.Name, extra.Path)
+			continue
+		}
+
+		g.path = keys
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: key, Line: extra.Line}
+		s, err := g.parseComments(keyNode, comments.KeyValueNodes("title", key))
+		g.path = nil
+		if err != nil {
+			return err
+		}
+
+		if parent.Properties == nil {
+			parent.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+		}
+		parent.Properties.Set(key, s)
+	}
+
+	return nil
+}
@@ -1,29 +1,68 @@
 package schema
 
 import (
+	"errors"
 	"fmt"
 	"helmvalues/pkg"
 	"helmvalues/pkg/schema/comments"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 
-	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"go.yaml.in/yaml/v4"
 )
 
 const JsonSchemaURI = "http://json-schema.org/draft-07/schema#"
 
+var dateOnlyPattern = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}$`)
+
 type Generator struct {
 	logger *logrus.Logger
 	plan   *Plan
+
+	root *yaml.Node
+
+	// rootComment holds the doc comment of the root schema, if any
+	rootComment *yaml.Node
+
+	// omitDefaults is non-zero while building sequence items and shared
+	// definitions, neither of which carry defaults
+	omitDefaults int
+
+	// anchorKeys maps anchored nodes to the node holding their doc comment
+	anchorKeys map[*yaml.Node]*yaml.Node
+
+	// definitions holds the schemas of anchored nodes when anchors are emitted
+	// as shared definitions, keyed by definition name
+	definitions     *pkg.EncodableOrderedMap[string, *pkg.JsonSchema]
+	definitionNames map[*yaml.Node]string
+
+	// resolving holds the anchored nodes currently being inlined
+	resolving map[*yaml.Node]bool
+
+	// comments reads doc comments in the configured dialect
+	comments comments.CommentReader
+
+	// path holds the keys of the value being built, with sequence items
+	// written as indices (eg: tolerations, [0], key)
+	path []string
+
+	// additionalProperties holds the policy of the subtree being built, which
+	// starts as the chart-wide policy and can be overridden per mapping
+	additionalProperties AdditionalPropertiesPolicy
 }
 
 func NewGenerator(logger *logrus.Logger, plan *Plan) *Generator {
 	return &Generator{
-		logger: logger,
-		plan:   plan,
+		logger:          logger,
+		plan:            plan,
+		definitions:     pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema](),
+		definitionNames: map[*yaml.Node]string{},
+		resolving:       map[*yaml.Node]bool{},
+
+		additionalProperties: plan.AdditionalProperties(),
 	}
 }
 
@@ -43,158 +82,620 @@ func (g *Generator) Generate() (*pkg.JsonSchema, error) {
 		return nil, fmt.Errorf("expected document node, got %d", rootNode.Kind)
 	}
 
-	s, err := g.buildMappingNode(nil, rootNode.Content[0])
+	g.root = rootNode.Content[0]
+	g.rootComment = rootCommentNode(rootNode)
+	g.anchorKeys = anchorKeys(rootNode)
+	g.comments = comments.NewCommentReader(g.plan.CommentDialect(), rootNode, g.plan.DetachedMarker())
+
+	g.definitions, err = readDefinitionsFile(g.plan.chart.DefinitionsFilePath())
 	if err != nil {
 		return nil, err
 	}
-	s.Schema = JsonSchemaURI
+
+	s, err := g.buildMappingNode(nil, g.root)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.addExtraValues(s); err != nil {
+		return nil, err
+	}
+	if s.Schema == "" {
+		s.Schema = JsonSchemaURI
+	}
+	if g.definitions.Len() > 0 {
+		if s.Definitions == nil {
+			s.Definitions = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+		}
+		for name, definition := range g.definitions.AllFromFront() {
+			s.Definitions.Set(name, definition)
+		}
+	}
+	if g.plan.ComposeSubcharts() {
+		if err := g.composeSubcharts(s); err != nil {
+			return nil, err
+		}
+	}
+	if g.plan.BundleRefs() {
+		bundler := NewRefBundler(g.logger, g.plan.chart.RootPath(), g.plan.RefCacheDir())
+		if err := bundler.Bundle(s); err != nil {
+			return nil, err
+		}
+	}
+	g.normalizeExclusiveLimits(s)
+	if err := g.checkLocalRefs(s); err != nil {
+		return nil, err
+	}
 	g.logger.Tracef("schmea generator, properties: %+v", s.Properties)
 
 	s.WalkProperties(
 		g.warnUndocumentedValue,
 		g.warnUntypedValue,
+		g.warnRequiredDefault,
 	)
 
 	return s, err
 }
 
 func (g *Generator) buildScalarNode(key *yaml.Node, value *yaml.Node) (*pkg.JsonSchema, error) {
-	valueType, err := yamlTagToSchema(value.Tag)
-	if err != nil {
-		return nil, err
-	}
-
 	extraNodes := []*yaml.Node{}
-	if valueType != "null" {
-		extraNodes = append(extraNodes, comments.KeyValueNodes("type", valueType)...)
+	extraNodes = append(extraNodes, g.scalarTypeNodes(key, value)...)
+	if key != nil {
+		extraNodes = append(extraNodes, comments.KeyValueNodes("title", key.Value)...)
+	}
+	if g.omitDefaults == 0 {
+		defaultNodes, err := g.defaultNodes(value)
+		if err != nil {
+			return nil, err
+		}
+		extraNodes = append(extraNodes, defaultNodes...)
 	}
-	extraNodes = append(extraNodes, comments.KeyValueNodes("title", key.Value)...)
-	extraNodes = append(extraNodes, comments.KeyValueNodes("default", value.Value)...)
 
-	s, err := comments.Parse(key, extraNodes)
+	s, err := g.parseComments(commentNode(key, value), extraNodes)
 	if err != nil {
-		if cErr, ok := err.(*comments.CommentError); ok {
-			cErr.Filepath = g.plan.chart.ValuesFilePath()
-			cErr.RenderToLog(g.logger)
-		}
+		return nil, err
+	}
+	g.applyTrailingComments(key, value, s)
 
-		err := fmt.Errorf("doc comment error: %w", err)
-		if g.plan.StrictComments() {
-			return nil, err
-		} else {
-			g.logger.Warn(err.Error())
-		}
+	// A null default with a declared type means the value is optional
+	if value.Tag == "!!null" {
+		s.Type = s.Type.WithNull()
 	}
 
 	return s, nil
 }
 
-// TODO: Finish handling sequences
-func (g *Generator) buildSequenceNode(key *yaml.Node, _ *yaml.Node) (*pkg.JsonSchema, error) {
+func (g *Generator) buildSequenceNode(key *yaml.Node, value *yaml.Node) (*pkg.JsonSchema, error) {
 	extraNodes := []*yaml.Node{}
 	extraNodes = append(extraNodes, comments.KeyValueNodes("type", "array")...)
 
-	// Not all objects will have a yaml key node, only set key values if they exist
-	if key == nil {
-		s := &pkg.JsonSchema{}
-		s.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
-		return s, nil
+	// Items of a sequence are never given a default, since the values are only
+	// examples of what the list may contain
+	if g.omitDefaults == 0 {
+		defaultNodes, err := g.defaultNodes(value)
+		if err != nil {
+			return nil, err
+		}
+		extraNodes = append(extraNodes, defaultNodes...)
+	}
+
+	// Not all sequences will have a yaml key node, only set key values if they exist
+	if key != nil {
+		extraNodes = append(extraNodes, comments.KeyValueNodes("title", key.Value)...)
 	}
 
-	extraNodes = append(extraNodes, comments.KeyValueNodes("title", key.Value)...)
+	s, err := g.parseComments(commentNode(key, value), extraNodes)
+	if err != nil {
+		return nil, err
+	}
+	g.applyTrailingComments(key, value, s)
 
-	s, err := comments.Parse(key, extraNodes)
+	// Items declared in the doc comment take precedence over inferred items
+	if s.Items != nil {
+		return s, nil
+	}
+
+	items, err := g.buildSequenceItems(value)
 	if err != nil {
-		if cErr, ok := err.(*comments.CommentError); ok {
-			cErr.Filepath = g.plan.chart.ValuesFilePath()
-			cErr.RenderToLog(g.logger)
-		}
+		return nil, err
+	}
+	if items != nil {
+		s.Items = items
+	}
 
-		err := fmt.Errorf("doc comment error: %w", err)
-		if g.plan.StrictComments() {
+	return s, nil
+}
+
+// buildSequenceItems infers a single items schema from the elements of the
+// sequence. Returns nil when the sequence is empty.
+func (g *Generator) buildSequenceItems(value *yaml.Node) (*pkg.JsonSchema, error) {
+	g.omitDefaults++
+	defer func() { g.omitDefaults-- }()
+
+	itemSchemas := []*pkg.JsonSchema{}
+	for i, item := range value.Content {
+		g.path = append(g.path, fmt.Sprintf("[%d]", i))
+		itemSchema, err := g.buildNode(nil, item)
+		g.path = g.path[:len(g.path)-1]
+		if err != nil {
+			g.logger.Debugf("Error building sequence item on line %d: %v", item.Line, err)
 			return nil, err
 		}
+		// A null item stands for itself, unlike a null value which is left
+		// untyped to be set to anything
+		if item.Tag == "!!null" && len(itemSchema.Type) == 0 && itemSchema.Ref == "" {
+			itemSchema.Type = pkg.NewSchemaType(pkg.NullType)
+		}
+		itemSchemas = append(itemSchemas, itemSchema)
 	}
 
-	return s, nil
+	return mergeItemSchemas(itemSchemas), nil
 }
 
 func (g *Generator) buildMappingNode(key *yaml.Node, value *yaml.Node) (*pkg.JsonSchema, error) {
 	extraNodes := []*yaml.Node{}
 	extraNodes = append(extraNodes, comments.KeyValueNodes("type", "object")...)
-	extraNodes = append(extraNodes, comments.KeyValueNodes("additionalProperties", "false")...)
 
 	// Not all objects will have a yaml key node, only set key values if they exist
 	s := &pkg.JsonSchema{}
 	if key != nil {
 		extraNodes = append(extraNodes, comments.KeyValueNodes("title", key.Value)...)
-
-		var err error
-		s, err = comments.Parse(key, extraNodes)
-		if err != nil {
-			if cErr, ok := err.(*comments.CommentError); ok {
-				cErr.Filepath = g.plan.chart.ValuesFilePath()
-				cErr.RenderToLog(g.logger)
-			}
-
-			err := fmt.Errorf("doc comment error: %w", err)
-			if g.plan.StrictComments() {
+		if g.omitDefaults == 0 {
+			defaultNodes, err := g.defaultNodes(value)
+			if err != nil {
 				return nil, err
 			}
+			extraNodes = append(extraNodes, defaultNodes...)
+		}
+	}
+	if value != g.root {
+		var err error
+		s, err = g.parseComments(commentNode(key, value), extraNodes)
+		if err != nil {
+			return nil, err
 		}
+		g.applyTrailingComments(key, value, s)
+	} else if g.rootComment != nil {
+		var err error
+		s, err = g.parseComments(g.rootComment, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The object is described by its comment alone, its keys are left untyped
+	if s.SkipProperties {
+		return s, nil
 	}
 	s.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
 
-	for _, child := range lo.Chunk(value.Content, 2) {
+	// The properties of a definition carry no defaults, the value's default
+	// stays with the reference to the definition
+	if s.Define != "" {
+		g.omitDefaults++
+		defer func() { g.omitDefaults-- }()
+	}
+
+	// A policy set in the doc comment applies to the whole subtree
+	if s.AdditionalPropertiesPolicy != "" {
+		policy, err := NewAdditionalPropertiesPolicy(s.AdditionalPropertiesPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", value.Line, err)
+		}
+		defer func(parent AdditionalPropertiesPolicy) { g.additionalProperties = parent }(g.additionalProperties)
+		g.additionalProperties = policy
+	}
+
+	pairs, err := MappingPairs(value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, child := range pairs {
 		childKey := child[0]
 		childValue := child[1]
 
-		var err error
-		var childValueSchema *pkg.JsonSchema
-		switch childValue.Kind {
-		case yaml.ScalarNode:
-			childValueSchema, err = g.buildScalarNode(childKey, childValue)
-			if err != nil {
-				g.logger.Debugf("Error building scalar node for key %s: %v", childKey.Value, err)
-				return nil, err
-			}
-		case yaml.SequenceNode:
-			childValueSchema, err = g.buildSequenceNode(childKey, childValue)
-			if err != nil {
-				g.logger.Debugf("Error building sequence node for key %s: %v", childKey.Value, err)
-				return nil, err
-			}
-		case yaml.MappingNode:
-			childValueSchema, err = g.buildMappingNode(childKey, childValue)
-			if err != nil {
-				g.logger.Debugf("Error building mapping node for key %s: %v", childKey.Value, err)
-				return nil, err
-			}
-		default:
-			// should be impossible
-			return nil, fmt.Errorf("unsupported yaml type: %v", childValue.Kind)
+		g.path = append(g.path, childKey.Value)
+		childValueSchema, err := g.buildNode(childKey, childValue)
+		g.path = g.path[:len(g.path)-1]
+		if err != nil {
+			return nil, err
 		}
 
 		s.Properties.Set(childKey.Value, childValueSchema)
+		if childValueSchema.RequiredValue && !slices.Contains(s.Required, childKey.Value) {
+			s.Required = append(s.Required, childKey.Value)
+		}
 	}
 
-	// If there are no properties described in the docs, allow additional properties by default
-	//
-	// TODO: This isn't quite right - we should only do this if additionalProperties isn't
-	// explicitly set to false, or if $schema or $ref hasn't been set
-	if len(slices.Collect(s.Properties.Keys())) == 0 {
-		s.AdditionalProperties = true
+	// The policy never overrides an explicit value, and isn't applied to the
+	// root schema (which receives global values from parent charts) or to
+	// schemas whose properties are described by a $ref.
+	if value != g.root && s.AdditionalProperties == nil && s.Ref == "" {
+		s.AdditionalProperties = g.additionalPropertiesValue(s)
 	}
 
 	return s, nil
 }
 
+// additionalPropertiesValue returns the additionalProperties value the current
+// policy gives the object schema, or nil to leave it unset.
+func (g *Generator) additionalPropertiesValue(s *pkg.JsonSchema) any {
+	switch g.additionalProperties {
+	case AdditionalPropertiesStrict:
+		return false
+	case AdditionalPropertiesStrictExceptEmpty:
+		// keys matching patternProperties are declared, so the object isn't empty
+		return s.Properties.Len() == 0 && (s.PatternProperties == nil || s.PatternProperties.Len() == 0)
+	default:
+		return nil
+	}
+}
+
+// buildNode builds the schema for the value node. The key node is nil for
+// sequence items.
+func (g *Generator) buildNode(key *yaml.Node, value *yaml.Node) (*pkg.JsonSchema, error) {
+	if value.Kind == yaml.AliasNode {
+		return g.buildAliasNode(key, value)
+	}
+
+	if value.Anchor != "" && g.plan.AnchorDefinitions() {
+		return g.buildDefinitionRef(key, value, commentNode(key, value))
+	}
+
+	s, err := g.buildValueNode(key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Define != "" {
+		return g.defineSchema(s)
+	}
+
+	return s, nil
+}
+
+// buildAliasNode builds the schema of the anchored node for the alias. The alias
+// inherits the anchor's doc comment when it has none of its own.
+func (g *Generator) buildAliasNode(key *yaml.Node, value *yaml.Node) (*pkg.JsonSchema, error) {
+	anchored := ResolveAlias(value)
+	if anchored.Kind == yaml.AliasNode {
+		return nil, fmt.Errorf("unknown anchor %s on line %d", value.Value, value.Line)
+	}
+
+	source := commentNode(key, value)
+	if anchorKey, ok := g.anchorKeys[anchored]; ok && source.HeadComment == "" {
+		source = withHeadComment(source, anchorKey.HeadComment)
+		source.Line = anchorKey.Line
+	}
+
+	if g.plan.AnchorDefinitions() {
+		return g.buildDefinitionRef(key, anchored, source)
+	}
+
+	if g.resolving[anchored] {
+		return nil, fmt.Errorf("recursive alias %s on line %d", value.Value, value.Line)
+	}
+	g.resolving[anchored] = true
+	defer delete(g.resolving, anchored)
+
+	if key != nil {
+		return g.buildValueNode(source, anchored)
+	}
+	return g.buildValueNode(nil, withHeadComment(anchored, source.HeadComment))
+}
+
+// buildDefinitionRef builds the anchored node as a shared definition, and
+// returns a schema referencing the definition. The doc comment of the source
+// node describes the reference rather than the definition.
+func (g *Generator) buildDefinitionRef(key *yaml.Node, anchored *yaml.Node, source *yaml.Node) (*pkg.JsonSchema, error) {
+	name, ok := g.definitionNames[anchored]
+	if !ok {
+		name = anchored.Anchor
+		for i := 2; g.definitions.Has(name); i++ {
+			name = fmt.Sprintf("%s-%d", anchored.Anchor, i)
+		}
+		g.definitionNames[anchored] = name
+
+		g.omitDefaults++
+		definition, err := g.buildValueNode(nil, withHeadComment(anchored, ""))
+		g.omitDefaults--
+		if err != nil {
+			return nil, err
+		}
+		g.definitions.Set(name, definition)
+	}
+
+	extraNodes := []*yaml.Node{}
+	extraNodes = append(extraNodes, comments.KeyValueNodes("$ref", definitionRef(name))...)
+	if key != nil {
+		extraNodes = append(extraNodes, comments.KeyValueNodes("title", key.Value)...)
+	}
+	if g.omitDefaults == 0 {
+		defaultNodes, err := g.defaultNodes(anchored)
+		if err != nil {
+			return nil, err
+		}
+		extraNodes = append(extraNodes, defaultNodes...)
+	}
+
+	return g.parseComments(source, extraNodes)
+}
+
+// defaultNodes returns the nodes setting the value as the schema default.
+func (g *Generator) defaultNodes(value *yaml.Node) ([]*yaml.Node, error) {
+	resolved, err := ResolvedCopy(value)
+	if err != nil {
+		return nil, err
+	}
+	literalScalars(resolved)
+	return comments.KeyNodes("default", resolved), nil
+}
+
+// literalScalars retags scalars whose decoded value differs from their text
+// in the values file, so defaults appear exactly as they were written.
+// Timestamps would otherwise be reformatted and binary values decoded.
+func literalScalars(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode {
+		switch {
+		case node.Tag == "!!timestamp" || node.Tag == "!!binary":
+			node.Tag = "!!str"
+		case !strings.HasPrefix(node.Tag, "!!"):
+			// local tags are dropped so the value resolves as a plain scalar
+			node.Tag = ""
+		}
+	}
+
+	for _, child := range node.Content {
+		literalScalars(child)
+	}
+}
+
+// scalarTypeNodes returns the schema nodes describing the type of the scalar.
+// Tags declared in the tag schema table take precedence over the builtin tags,
+// and unknown tags produce an untyped schema.
+func (g *Generator) scalarTypeNodes(key *yaml.Node, value *yaml.Node) []*yaml.Node {
+	if tagSchema, ok := g.plan.TagSchema(value.Tag); ok {
+		return tagSchema.Content
+	}
+
+	switch value.Tag {
+	case "!!timestamp":
+		format := "date-time"
+		if dateOnlyPattern.MatchString(value.Value) {
+			format = "date"
+		}
+		return append(
+			comments.KeyValueNodes("type", "string"),
+			comments.KeyValueNodes("format", format)...,
+		)
+	case "!!binary":
+		return append(
+			comments.KeyValueNodes("type", "string"),
+			comments.KeyValueNodes("contentEncoding", "base64")...,
+		)
+	}
+
+	valueType, err := yamlTagToSchema(value.Tag)
+	if err != nil {
+		g.logger.Warnf("%v, leaving %s untyped", err, nodeName(key, value))
+		return nil
+	}
+	if valueType == "null" {
+		return nil
+	}
+
+	return comments.KeyValueNodes("type", valueType)
+}
+
+// buildValueNode builds the schema for the value node based on its kind.
+func (g *Generator) buildValueNode(key *yaml.Node, value *yaml.Node) (*pkg.JsonSchema, error) {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		s, err := g.buildScalarNode(key, value)
+		if err != nil {
+			g.logger.Debugf("Error building scalar node for %s: %v", nodeName(key, value), err)
+		}
+		return s, err
+	case yaml.SequenceNode:
+		s, err := g.buildSequenceNode(key, value)
+		if err != nil {
+			g.logger.Debugf("Error building sequence node for %s: %v", nodeName(key, value), err)
+		}
+		return s, err
+	case yaml.MappingNode:
+		s, err := g.buildMappingNode(key, value)
+		if err != nil {
+			g.logger.Debugf("Error building mapping node for %s: %v", nodeName(key, value), err)
+		}
+		return s, err
+	default:
+		// should be impossible
+		return nil, fmt.Errorf("unsupported yaml type: %v", value.Kind)
+	}
+}
+
+// keyPath returns the dotted key path of the value being built, eg: tolerations[0].key
+func (g *Generator) keyPath() string {
+	return strings.ReplaceAll(strings.Join(g.path, "."), ".[", "[")
+}
+
+// parseComments parses the doc comment of the node into a schema. Comment errors
+// are only returned in strict mode, otherwise they're logged and the schema is
+// built from the extra nodes alone.
+func (g *Generator) parseComments(node *yaml.Node, extraNodes []*yaml.Node) (*pkg.JsonSchema, error) {
+	s, err := comments.ParseWith(g.comments, node, g.keyPath(), extraNodes)
+	if err == nil {
+		g.warnUntypedNullable(s)
+		return s, nil
+	}
+
+	if cErr, ok := err.(*comments.CommentError); ok {
+		cErr.Filepath = g.plan.chart.ValuesFilePath()
+		cErr.RenderToLog(g.logger)
+	}
+
+	err = fmt.Errorf("doc comment error: %w", err)
+	if g.plan.StrictComments() {
+		return nil, err
+	}
+	g.logger.Warn(err.Error())
+
+	// unknown keywords are dropped while the rest of the comment still applies
+	if errors.Is(err, comments.ErrUnknownKeyword) {
+		return s, nil
+	}
+
+	return comments.Parse(&yaml.Node{}, extraNodes)
+}
+
+// warnUntypedNullable warns about nullable values without a declared or
+// inferred type, which have no type to add null to.
+func (g *Generator) warnUntypedNullable(s *pkg.JsonSchema) {
+	if !s.Nullable {
+		return
+	}
+	g.logger.Warnf("nullable has no effect without a type, leaving %s untyped", g.keyPath())
+}
+
+// applyTrailingComments describes the value with its line comment when the
+// head comment gave no description. Foot comments are appended to the
+// description when enabled. The comment used is logged, since it isn't always
+// obvious which comment a description came from.
+func (g *Generator) applyTrailingComments(key *yaml.Node, value *yaml.Node, s *pkg.JsonSchema) {
+	name := g.plan.chart.Details.Name
+	path := g.keyPath()
+
+	lineComment := commentText(commentNode(key, value).LineComment, " ")
+	if lineComment == "" {
+		lineComment = commentText(value.LineComment, " ")
+	}
+	if lineComment != "" {
+		if s.Description == "" {
+			g.logger.Debugf("schema: %s: %s: description read from line comment", name, path)
+			s.Description = lineComment
+		} else {
+			g.logger.Debugf("schema: %s: %s: head comment takes precedence over line comment", name, path)
+		}
+	}
+
+	footComment := commentText(commentNode(key, value).FootComment, "\n")
+	if footComment == "" {
+		return
+	}
+	switch {
+	case !g.plan.FootComments():
+		g.logger.Debugf("schema: %s: %s: foot comment ignored, foot comments are disabled", name, path)
+	case s.Description == "":
+		g.logger.Debugf("schema: %s: %s: description read from foot comment", name, path)
+		s.Description = footComment
+	default:
+		g.logger.Debugf("schema: %s: %s: foot comment appended to description", name, path)
+		s.Description = s.Description + "\n\n" + footComment
+	}
+}
+
+// commentText returns the text of the comment, with the lines joined by the
+// separator.
+func commentText(comment string, separator string) string {
+	lines := []string{}
+	for _, line := range strings.Split(comment, "\n") {
+		if line = strings.TrimSpace(strings.TrimLeft(line, "#")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, separator)
+}
+
+// commentNode returns the node holding the doc comment. Sequence items have
+// no key, so their comments are attached to the value node itself.
+func commentNode(key *yaml.Node, value *yaml.Node) *yaml.Node {
+	if key != nil {
+		return key
+	}
+	return value
+}
+
+func withHeadComment(node *yaml.Node, comment string) *yaml.Node {
+	c := *node
+	c.HeadComment = comment
+	return &c
+}
+
+func nodeName(key *yaml.Node, value *yaml.Node) string {
+	if key != nil {
+		return fmt.Sprintf("key %s", key.Value)
+	}
+	return fmt.Sprintf("item on line %d", value.Line)
+}
+
+// mergeItemSchemas reduces the schemas of each sequence item into a single
+// items schema. Items of the same type are merged together, and items of
+// differing types are combined with anyOf.
+func mergeItemSchemas(schemas []*pkg.JsonSchema) *pkg.JsonSchema {
+	variants := []*pkg.JsonSchema{}
+	for _, s := range schemas {
+		idx := slices.IndexFunc(variants, func(v *pkg.JsonSchema) bool {
+			return slices.Equal(v.Type, s.Type)
+		})
+		if idx == -1 {
+			variants = append(variants, s)
+			continue
+		}
+		variants[idx] = mergeSchemas(variants[idx], s)
+	}
+
+	switch len(variants) {
+	case 0:
+		return nil
+	case 1:
+		return variants[0]
+	default:
+		return &pkg.JsonSchema{AnyOf: variants}
+	}
+}
+
+// mergeSchemas merges two schemas of the same type. Values set on a take
+// precedence, object properties are combined, and item schemas are merged.
+func mergeSchemas(a *pkg.JsonSchema, b *pkg.JsonSchema) *pkg.JsonSchema {
+	merged := *a
+	if merged.Description == "" {
+		merged.Description = b.Description
+	}
+
+	if a.Properties != nil && b.Properties != nil {
+		merged.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+		for k, v := range a.Properties.AllFromFront() {
+			merged.Properties.Set(k, v)
+		}
+		for k, v := range b.Properties.AllFromFront() {
+			if existing, ok := merged.Properties.Get(k); ok {
+				v = mergeItemSchemas([]*pkg.JsonSchema{existing, v})
+			}
+			merged.Properties.Set(k, v)
+		}
+		// items that disallow additional properties keep doing so once merged
+		if a.AdditionalProperties == false || b.AdditionalProperties == false {
+			merged.AdditionalProperties = false
+		}
+	}
+
+	aItems, aOk := a.Items.(*pkg.JsonSchema)
+	bItems, bOk := b.Items.(*pkg.JsonSchema)
+	if aOk && bOk {
+		merged.Items = mergeItemSchemas([]*pkg.JsonSchema{aItems, bItems})
+	} else if !aOk && bOk {
+		merged.Items = bItems
+	}
+
+	return &merged
+}
+
 func yamlTagToSchema(tag string) (string, error) {
 	switch tag {
 	case "!!str":
 		return "string", nil
 	case "!!int":
-		return "number", nil
+		return "integer", nil
 	case "!!float":
 		return "number", nil
 	case "!!bool":
@@ -206,7 +707,7 @@ func yamlTagToSchema(tag string) (string, error) {
 	case "!!null":
 		return "null", nil
 	default:
-		return "", fmt.Errorf("unsupported yaml tag: %s", tag)
+		return "", fmt.Errorf("unsupported yaml tag %s", tag)
 	}
 }
 
@@ -215,8 +716,9 @@ func isDocumented(schemaPath []*pkg.JsonSchema) bool {
 		return true
 	}
 
+	// values left out of the docs on purpose don't need a description
 	for _, s := range schemaPath {
-		if s.Ref != "" {
+		if s.Ref != "" || s.DocsIgnored {
 			return true
 		}
 	}
@@ -224,40 +726,78 @@ func isDocumented(schemaPath []*pkg.JsonSchema) bool {
 	return false
 }
 
+// valuePath returns the dotted key path of the value at the end of the schema
+// path. The root schema is left out, its title names the values file and not
+// a key.
+func valuePath(schemaPath []*pkg.JsonSchema) string {
+	keyValues := []string{}
+	for _, k := range schemaPath[1:] {
+		if k.Title == "" {
+			continue
+		}
+		keyValues = append(keyValues, k.Title)
+	}
+	return strings.Join(keyValues, ".")
+}
+
 func (g *Generator) warnUndocumentedValue(keyPath []*pkg.JsonSchema, schema *pkg.JsonSchema) {
+	// the root schema isn't a value
+	if len(keyPath) == 0 {
+		return
+	}
+
 	if !isDocumented(append(keyPath, schema)) {
 		if schema.Title == "" {
 			return
 		}
 
-		keyValues := []string{}
-		for _, k := range append(keyPath, schema) {
-			if k.Title == "" {
-				continue
-			}
-			keyValues = append(keyValues, k.Title)
-		}
-
-		g.logger.Warnf("value is undocumented: %s", strings.Join(keyValues, "."))
+		g.logger.Warnf("value is undocumented: %s", valuePath(append(keyPath, schema)))
 	}
 }
 
 func (g *Generator) warnUntypedValue(keyPath []*pkg.JsonSchema, schema *pkg.JsonSchema) {
-	if schema.Title == "" {
+	if len(keyPath) == 0 || schema.Title == "" {
 		return
 	}
 
-	if schema.Type != "" {
+	// the referenced schema gives the value its type
+	if len(schema.Type) > 0 || schema.Ref != "" {
 		return
 	}
 
-	keyValues := []string{}
-	for _, k := range append(keyPath, schema) {
-		if k.Title == "" {
+	g.logger.Warnf("value has no type: %s", valuePath(append(keyPath, schema)))
+}
+
+// warnRequiredDefault warns about required values with a non-empty default,
+// which are always set by the values file and so never need to be provided.
+func (g *Generator) warnRequiredDefault(keyPath []*pkg.JsonSchema, schema *pkg.JsonSchema) {
+	if schema.Properties == nil {
+		return
+	}
+
+	for _, name := range schema.Required {
+		prop, ok := schema.Properties.Get(name)
+		if !ok || isEmptyValue(prop.Default) {
 			continue
 		}
-		keyValues = append(keyValues, k.Title)
+
+		g.logger.Warnf("value is required but has a default: %s", valuePath(append(keyPath, schema, prop)))
 	}
+}
 
-	g.logger.Warnf("value has no type: %s", strings.Join(keyValues, "."))
+// isEmptyValue reports whether the default value is null, or an empty string,
+// list or map.
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	default:
+		return false
+	}
 }
@@ -0,0 +1,1319 @@
+package schema
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"helmvalues/internal/charts"
+	"helmvalues/pkg"
+	"helmvalues/pkg/schema/comments"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const TEST_CHART_YAML = `
+apiVersion: v2
+name: test-chart
+description: A chart for testing
+version: 0.1.0
+`
+
+const SEQUENCE_OF_SCALARS = `
+# hosts to serve
+hosts:
+  - foo.example.com
+  - bar.example.com
+`
+
+const SEQUENCE_OF_MIXED_SCALARS = `
+# mixed values
+mixed:
+  - foo
+  - 3
+  - true
+  - bar
+`
+
+const SEQUENCE_WITH_NULL_ITEM = `
+# mixed values
+mixed: [1, "a", 2.5, null]
+`
+
+const SEQUENCE_OF_MAPS = `
+# pod tolerations
+tolerations:
+  # first toleration
+  - key: foo
+    operator: Equal
+  - key: bar
+    effect: NoSchedule
+`
+
+const SEQUENCE_WITH_DECLARED_ITEMS = `
+# items:
+#   type: integer
+# ---
+# ports to expose
+ports:
+  - http
+`
+
+const EMPTY_SEQUENCE = `
+# extra environment variables
+extraEnv: []
+`
+
+const UMBRELLA_CHART_YAML = `
+apiVersion: v2
+name: umbrella
+version: 1.0.0
+dependencies:
+  - name: redis
+    version: 1.0.0
+    condition: redis.enabled
+  - name: postgres
+    version: 2.0.0
+    alias: db
+    condition: db.enabled,global.db.enabled
+    tags:
+      - storage
+  - name: missing
+    version: 0.1.0
+`
+
+const UMBRELLA_VALUES = `
+global:
+  # cluster domain
+  domain: cluster.local
+redis:
+  # number of redis replicas
+  replicas: 3
+  auth:
+    password: null
+`
+
+const REDIS_CHART_YAML = `
+apiVersion: v2
+name: redis
+version: 1.0.0
+`
+
+const REDIS_VALUES = `
+# enables redis
+enabled: true
+# redis replicas
+replicas: 1
+auth:
+  # redis user
+  username: default
+  # redis password
+  password: secret
+global:
+  # image registry
+  imageRegistry: docker.io
+`
+
+const POSTGRES_CHART_YAML = `
+apiVersion: v2
+name: postgres
+version: 2.0.0
+`
+
+const POSTGRES_VALUES = `
+# database port
+port: &port 5432
+# replica port
+replicaPort: *port
+`
+
+const ADDITIONAL_PROPERTIES = `
+image:
+  repository: nginx
+# pod annotations
+podAnnotations: {}
+# additionalPropertiesPolicy: lax
+extraConfig:
+  nested:
+    key: value
+# additionalProperties: true
+labels:
+  app: nginx
+# $ref: https://example.com/probe.json
+probe:
+  periodSeconds: 10
+`
+
+const UNKNOWN_KEYWORDS = `
+# minLength: 3
+# constant: foo
+# $comment: keep this short
+name: foo
+`
+
+const NUMERIC_CONSTRAINTS = `
+# minimum: 0
+# multipleOf: 0.25
+# ---
+# cpu request
+cpu: 0.5
+# minimum: 1
+# exclusiveMinimum: true
+# maximum: 10
+# exclusiveMaximum: 11
+# ---
+# replica count
+replicas: 3
+# minLength: 0
+# ---
+# name override
+nameOverride: ""
+`
+
+const PATTERN_PROPERTIES = `
+# patternProperties:
+#   "^[a-z0-9.-/]+$":
+#     type: string
+#     maxLength: 63
+# ---
+# labels added to pods
+podLabels: {}
+`
+
+const EXTENSIONS = `
+# x-ui-widget: password
+# x-sensitive: true
+# x-group: credentials
+# ---
+# database password
+password: ""
+`
+
+const REQUIRED_VALUES = `
+# database settings
+database:
+  # required: true
+  # ---
+  # database host
+  host: ""
+  # @required
+  # database port
+  port: 5432
+  # database name
+  name: app
+`
+
+const REQUIRED_DEFINITION_VALUES = `
+# database settings
+database:
+  # @required
+  # define: endpoint
+  # ---
+  # primary endpoint
+  primary:
+    # endpoint host
+    host: ""
+  # @required
+  # replica endpoint
+  replica: &replica
+    # endpoint host
+    host: ""
+  # @required
+  # standby endpoint
+  standby: *replica
+  # $ref: "#/definitions/endpoint"
+  # @required
+  # ---
+  # backup endpoint
+  backup: {}
+`
+
+const HELM_DOCS_COMMENTS = `
+# image.tag -- image tag, defaults to the chart appVersion
+image:
+  # -- image repository
+  # pulled from docker hub
+  repository: nginx
+  tag: ""
+  # -- pull policy
+  # @default -- IfNotPresent
+  # @section -- Image
+  pullPolicy: ""
+`
+
+const BITNAMI_COMMENTS = `
+## @section Common parameters
+## @param nameOverride Partially overrides the chart name
+## @extra extraDeploy.manifests [array] Extra objects to deploy
+## @skip diagnosticMode
+nameOverride: ""
+diagnosticMode:
+  enabled: false
+`
+
+const SCHEMA_FENCES = `
+# @schema
+# required: true
+# @schema
+# -- Pod labels
+podLabels:
+  # @schema
+  # skipProperties: true
+  # @schema
+  # -- Free-form selector
+  selector:
+    app: demo
+`
+
+const TRAILING_COMMENTS = `
+port: 8080 # container port
+# Service settings
+service: # ignored, the head comment takes precedence
+  type: ClusterIP
+  # Only used by LoadBalancer services
+
+hosts:
+  - example.com # primary host
+`
+
+func TestGenerateSequences(t *testing.T) {
+	var tests = []struct {
+		name     string
+		values   string
+		validate func(tt *testing.T, s *pkg.JsonSchema)
+	}{
+		{
+			name:   "homogeneous scalars produce a single items schema",
+			values: SEQUENCE_OF_SCALARS,
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				hosts := property(tt, s, "hosts")
+				assert.Equal(tt, pkg.NewSchemaType("array"), hosts.Type)
+				assert.Equal(tt, []any{"foo.example.com", "bar.example.com"}, hosts.Default)
+				assert.Equal(tt, &pkg.JsonSchema{Type: pkg.NewSchemaType("string")}, hosts.Items)
+			},
+		},
+		{
+			name:   "mixed scalars produce anyOf items",
+			values: SEQUENCE_OF_MIXED_SCALARS,
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				items := property(tt, s, "mixed").Items.(*pkg.JsonSchema)
+				require.Len(tt, items.AnyOf, 3)
+				assert.Equal(tt, pkg.NewSchemaType("string"), items.AnyOf[0].Type)
+				assert.Equal(tt, pkg.NewSchemaType("boolean"), items.AnyOf[2].Type)
+			},
+		},
+		{
+			name:   "null items are typed null",
+			values: SEQUENCE_WITH_NULL_ITEM,
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				items := property(tt, s, "mixed").Items.(*pkg.JsonSchema)
+				require.Len(tt, items.AnyOf, 4)
+				assert.Equal(tt, pkg.NewSchemaType("integer"), items.AnyOf[0].Type)
+				assert.Equal(tt, pkg.NewSchemaType("string"), items.AnyOf[1].Type)
+				assert.Equal(tt, pkg.NewSchemaType("number"), items.AnyOf[2].Type)
+				assert.Equal(tt, &pkg.JsonSchema{Type: pkg.NewSchemaType("null")}, items.AnyOf[3])
+			},
+		},
+		{
+			name:   "maps produce merged object properties",
+			values: SEQUENCE_OF_MAPS,
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				items := property(tt, s, "tolerations").Items.(*pkg.JsonSchema)
+				assert.Equal(tt, pkg.NewSchemaType("object"), items.Type)
+				assert.Equal(tt, "first toleration", items.Description)
+				assert.Equal(tt, []string{"key", "operator", "effect"}, keys(items))
+
+				key, _ := items.Properties.Get("key")
+				assert.Nil(tt, key.Default)
+			},
+		},
+		{
+			name:   "items declared in comment are preserved",
+			values: SEQUENCE_WITH_DECLARED_ITEMS,
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				ports := property(tt, s, "ports")
+				assert.Equal(tt, "ports to expose", ports.Description)
+				assert.Equal(tt, &pkg.JsonSchema{Type: pkg.NewSchemaType("integer")}, ports.Items)
+			},
+		},
+		{
+			name:   "empty sequence has no items",
+			values: EMPTY_SEQUENCE,
+			validate: func(tt *testing.T, s *pkg.JsonSchema) {
+				extraEnv := property(tt, s, "extraEnv")
+				assert.Nil(tt, extraEnv.Items)
+				assert.Equal(tt, []any{}, extraEnv.Default)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			s, err := generateFromValues(tt, tc.values)
+			require.NoError(tt, err)
+
+			// the schema must always be serializable
+			_, err = json.Marshal(s)
+			require.NoError(tt, err)
+
+			tc.validate(tt, s)
+		})
+	}
+}
+
+const TYPED_SCALARS = `
+# number of replicas
+replicas: 3
+# cpu ratio
+ratio: 0.5
+# enable the thing
+enabled: true
+# quoted number
+quotedNumber: "3"
+# quoted boolean
+quotedBool: 'true'
+# version string
+version: 1.0.0
+`
+
+const TYPED_MAPPING = `
+# image settings
+image:
+  # image repository
+  repository: nginx
+  # image tag
+  tag: "1.25"
+  # pull secrets
+  pullSecrets: []
+`
+
+func TestGenerateTypedDefaults(t *testing.T) {
+	var tests = []struct {
+		name            string
+		values          string
+		key             string
+		expectedType    string
+		expectedDefault any
+	}{
+		{"integer", TYPED_SCALARS, "replicas", "integer", 3},
+		{"float", TYPED_SCALARS, "ratio", "number", 0.5},
+		{"boolean", TYPED_SCALARS, "enabled", "boolean", true},
+		{"quoted number", TYPED_SCALARS, "quotedNumber", "string", "3"},
+		{"quoted boolean", TYPED_SCALARS, "quotedBool", "string", "true"},
+		{"version string", TYPED_SCALARS, "version", "string", "1.0.0"},
+		{
+			"mapping",
+			TYPED_MAPPING,
+			"image",
+			"object",
+			map[string]any{"repository": "nginx", "tag": "1.25", "pullSecrets": []any{}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			s, err := generateFromValues(tt, tc.values)
+			require.NoError(tt, err)
+
+			prop := property(tt, s, tc.key)
+			assert.Equal(tt, pkg.NewSchemaType(tc.expectedType), prop.Type)
+			assert.Equal(tt, tc.expectedDefault, prop.Default)
+		})
+	}
+}
+
+const ANCHORS_AND_ALIASES = `
+# shared probe settings
+livenessProbe: &probe
+  # probe period
+  periodSeconds: 10
+readinessProbe: *probe
+# startup probe
+startupProbe: *probe
+# pod settings
+pod:
+  <<: *probe
+  # seconds to wait
+  periodSeconds: 5
+  # extra setting
+  extra: true
+`
+
+func TestGenerateAnchorsAndAliases(t *testing.T) {
+	s, err := generateFromValuesWithConfig(t, ANCHORS_AND_ALIASES, &Config{Strict: true})
+	require.NoError(t, err)
+
+	readiness := property(t, s, "readinessProbe")
+	assert.Equal(t, pkg.NewSchemaType("object"), readiness.Type)
+	assert.Equal(t, "shared probe settings", readiness.Description)
+	assert.Equal(t, map[string]any{"periodSeconds": 10}, readiness.Default)
+	assert.Equal(t, []string{"periodSeconds"}, keys(readiness))
+
+	startup := property(t, s, "startupProbe")
+	assert.Equal(t, "startup probe", startup.Description)
+
+	pod := property(t, s, "pod")
+	assert.Equal(t, []string{"periodSeconds", "extra"}, keys(pod))
+	assert.Equal(t, map[string]any{"periodSeconds": 5, "extra": true}, pod.Default)
+	period := property(t, pod, "periodSeconds")
+	assert.Equal(t, "seconds to wait", period.Description)
+	assert.Equal(t, 5, period.Default)
+}
+
+func TestGenerateAnchorDefinitions(t *testing.T) {
+	s, err := generateFromValuesWithConfig(t, ANCHORS_AND_ALIASES, &Config{Strict: true, AnchorDefinitions: true})
+	require.NoError(t, err)
+
+	require.NotNil(t, s.Definitions)
+	probe, ok := s.Definitions.Get("probe")
+	require.True(t, ok)
+	assert.Equal(t, pkg.NewSchemaType("object"), probe.Type)
+	assert.Nil(t, probe.Default)
+
+	for _, key := range []string{"livenessProbe", "readinessProbe", "startupProbe"} {
+		prop := property(t, s, key)
+		assert.Equal(t, "#/definitions/probe", prop.Ref)
+		assert.Equal(t, map[string]any{"periodSeconds": 10}, prop.Default)
+	}
+	assert.Equal(t, "shared probe settings", property(t, s, "readinessProbe").Description)
+
+	// merge keys are always expanded in place
+	assert.Equal(t, "", property(t, s, "pod").Ref)
+}
+
+const TAGGED_SCALARS = `
+# release time
+releasedAt: 2001-12-14t21:59:43.10-05:00
+# release date
+releasedOn: 2002-12-14
+# certificate bundle
+caBundle: !!binary aGVsbG8=
+# secret reference
+password: !secret db-password
+# vault reference
+token: !vault secret/token
+`
+
+func TestGenerateScalarTags(t *testing.T) {
+	tagSchemas := &yaml.Node{}
+	require.NoError(t, yaml.Unmarshal([]byte("type: string\nwriteOnly: true\n"), tagSchemas))
+	cfg := &Config{
+		Strict:     true,
+		TagSchemas: map[string]*yaml.Node{"!secret": tagSchemas.Content[0]},
+	}
+
+	s, err := generateFromValuesWithConfig(t, TAGGED_SCALARS, cfg)
+	require.NoError(t, err)
+
+	releasedAt := property(t, s, "releasedAt")
+	assert.Equal(t, pkg.NewSchemaType("string"), releasedAt.Type)
+	assert.Equal(t, "date-time", releasedAt.Format)
+	assert.Equal(t, "2001-12-14t21:59:43.10-05:00", releasedAt.Default)
+
+	releasedOn := property(t, s, "releasedOn")
+	assert.Equal(t, "date", releasedOn.Format)
+	assert.Equal(t, "2002-12-14", releasedOn.Default)
+
+	caBundle := property(t, s, "caBundle")
+	assert.Equal(t, pkg.NewSchemaType("string"), caBundle.Type)
+	assert.Equal(t, "base64", caBundle.ContentEncoding)
+	assert.Equal(t, "aGVsbG8=", caBundle.Default)
+
+	password := property(t, s, "password")
+	assert.Equal(t, pkg.NewSchemaType("string"), password.Type)
+	assert.True(t, password.WriteOnly)
+	assert.Equal(t, "db-password", password.Default)
+
+	token := property(t, s, "token")
+	assert.Empty(t, token.Type)
+	assert.Equal(t, "secret/token", token.Default)
+}
+
+const NULL_DEFAULTS = `
+# type: string
+# ---
+# name of an existing secret
+existingSecret: ~
+# name override
+nameOverride:
+`
+
+func TestGenerateNullDefaults(t *testing.T) {
+	s, err := generateFromValues(t, NULL_DEFAULTS)
+	require.NoError(t, err)
+
+	existingSecret := property(t, s, "existingSecret")
+	assert.Equal(t, pkg.NewSchemaType("string", "null"), existingSecret.Type)
+
+	encoded, err := json.Marshal(existingSecret.Type)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["string", "null"]`, string(encoded))
+
+	nameOverride := property(t, s, "nameOverride")
+	assert.Empty(t, nameOverride.Type)
+}
+
+const ROOT_DOCUMENT_COMMENT = `# yaml-language-server: $schema=values.schema.json
+
+# $id: https://example.com/values.schema.json
+# title: Test chart values
+# required: [image]
+# ---
+# Values for the test chart
+# @root
+
+# image to run
+image: nginx
+`
+
+const ROOT_LICENSE_COMMENT = `# Copyright The Example Authors
+# SPDX-License-Identifier: Apache-2.0
+
+# image to run
+image: nginx
+`
+
+const ROOT_TITLE_WARNINGS = `# title: Test chart values
+# @root
+# image to run
+image:
+  # image tag
+  tag: latest
+  pullPolicy: null
+`
+
+const ROOT_MARKER_COMMENT = `# title: Test chart values
+# additionalProperties: false
+# oneOf:
+#   - required: [image]
+#   - required: [digest]
+# @root
+# image to run
+image: nginx
+# image digest
+digest: ""
+`
+
+func TestGenerateUntypedNullable(t *testing.T) {
+	chart, err := charts.NewChart(writeChart(t, "# nullable: true\n# ---\n# the tag\ntag: !custom latest\n"))
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logOutput := &strings.Builder{}
+	logger.SetOutput(logOutput)
+
+	s, err := NewGenerator(logger, NewPlan(&Config{Strict: true}, chart)).Generate()
+	require.NoError(t, err)
+
+	assert.Empty(t, property(t, s, "tag").Type)
+	assert.Contains(t, logOutput.String(), "nullable has no effect without a type, leaving tag untyped")
+}
+
+func TestGenerateRootAttributes(t *testing.T) {
+	t.Run("document comment", func(tt *testing.T) {
+		s, err := generateFromValues(tt, ROOT_DOCUMENT_COMMENT)
+		require.NoError(tt, err)
+
+		assert.Equal(tt, JsonSchemaURI, s.Schema)
+		assert.Equal(tt, "https://example.com/values.schema.json", s.ID)
+		assert.Equal(tt, "Test chart values", s.Title)
+		assert.Equal(tt, "Values for the test chart", s.Description)
+		assert.Equal(tt, []string{"image"}, s.Required)
+		assert.Equal(tt, "image to run", property(tt, s, "image").Description)
+	})
+
+	t.Run("first key comment with root marker", func(tt *testing.T) {
+		s, err := generateFromValues(tt, ROOT_MARKER_COMMENT)
+		require.NoError(tt, err)
+
+		assert.Equal(tt, "Test chart values", s.Title)
+		assert.Equal(tt, false, s.AdditionalProperties)
+		require.Len(tt, s.OneOf, 2)
+		assert.Equal(tt, []string{"digest"}, s.OneOf[1].Required)
+
+		image := property(tt, s, "image")
+		assert.Equal(tt, "image to run", image.Description)
+		assert.Empty(tt, image.OneOf)
+	})
+
+	t.Run("modeline is not a root comment", func(tt *testing.T) {
+		s, err := generateFromValues(tt, "# yaml-language-server: $schema=values.schema.json\n\n# image to run\nimage: nginx\n")
+		require.NoError(tt, err)
+
+		assert.Equal(tt, "", s.Description)
+		assert.Equal(tt, "image to run", property(tt, s, "image").Description)
+	})
+
+	t.Run("document comment without root marker is ignored", func(tt *testing.T) {
+		s, err := generateFromValues(tt, ROOT_LICENSE_COMMENT)
+		require.NoError(tt, err)
+
+		assert.Equal(tt, "", s.Description)
+		assert.Equal(tt, "image to run", property(tt, s, "image").Description)
+	})
+
+	t.Run("root title is not part of value paths", func(tt *testing.T) {
+		chart, err := charts.NewChart(writeChart(tt, ROOT_TITLE_WARNINGS))
+		require.NoError(tt, err)
+
+		logger := logrus.New()
+		logOutput := &strings.Builder{}
+		logger.SetOutput(logOutput)
+
+		s, err := NewGenerator(logger, NewPlan(&Config{Strict: true}, chart)).Generate()
+		require.NoError(tt, err)
+
+		assert.Equal(tt, "Test chart values", s.Title)
+		assert.NotContains(tt, logOutput.String(), "Test chart values")
+		assert.Contains(tt, logOutput.String(), "value is undocumented: image.pullPolicy")
+		assert.Contains(tt, logOutput.String(), "value has no type: image.pullPolicy")
+	})
+}
+
+const INLINE_DEFINITIONS = `
+# define: probe
+# ---
+# liveness probe
+livenessProbe:
+  # probe period
+  periodSeconds: 10
+# $ref: "#/definitions/probe"
+# ---
+# readiness probe
+readinessProbe:
+  periodSeconds: 5
+# $ref: "#/definitions/image"
+# ---
+# container image
+image:
+  repository: nginx
+`
+
+const DEFINITIONS_FILE = `
+image:
+  type: object
+  properties:
+    repository:
+      type: string
+`
+
+func TestGenerateDefinitions(t *testing.T) {
+	t.Run("inline and file definitions", func(tt *testing.T) {
+		chartDir := writeChart(tt, INLINE_DEFINITIONS)
+		require.NoError(tt, os.WriteFile(filepath.Join(chartDir, "values.definitions.yaml"), []byte(DEFINITIONS_FILE), 0644))
+
+		s, err := generateChart(tt, chartDir, &Config{Strict: true})
+		require.NoError(tt, err)
+
+		require.NotNil(tt, s.Definitions)
+		assert.Equal(tt, []string{"image", "probe"}, slices.Collect(s.Definitions.Keys()))
+
+		probe, _ := s.Definitions.Get("probe")
+		assert.Equal(tt, pkg.NewSchemaType("object"), probe.Type)
+		assert.Equal(tt, "", probe.Description)
+		assert.Equal(tt, []string{"periodSeconds"}, keys(probe))
+		assert.Nil(tt, property(tt, probe, "periodSeconds").Default)
+
+		liveness := property(tt, s, "livenessProbe")
+		assert.Equal(tt, "#/definitions/probe", liveness.Ref)
+		assert.Equal(tt, "liveness probe", liveness.Description)
+		assert.Equal(tt, map[string]any{"periodSeconds": 10}, liveness.Default)
+
+		readiness := property(tt, s, "readinessProbe")
+		assert.Equal(tt, "#/definitions/probe", readiness.Ref)
+		assert.Equal(tt, map[string]any{"periodSeconds": 5}, readiness.Default)
+	})
+
+	t.Run("unresolved references fail in strict mode", func(tt *testing.T) {
+		_, err := generateFromValues(tt, INLINE_DEFINITIONS)
+		assert.ErrorContains(tt, err, "#/definitions/image")
+	})
+}
+
+const BUNDLED_REFS = `
+# $ref: schemas/probe.json
+# ---
+# liveness probe
+livenessProbe: {}
+# $ref: https://example.com/k8s/_definitions.json#/definitions/io.k8s.api.core.v1.ResourceRequirements
+# ---
+# container resources
+resources: {}
+`
+
+const PROBE_SCHEMA = `{
+  "type": "object",
+  "properties": {
+    "periodSeconds": {"type": "integer"}
+  }
+}`
+
+const K8S_DEFINITIONS = `{
+  "definitions": {
+    "io.k8s.api.core.v1.ResourceRequirements": {
+      "type": "object",
+      "properties": {
+        "limits": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.api.resource.Quantity"}
+      }
+    },
+    "io.k8s.apimachinery.pkg.api.resource.Quantity": {
+      "type": "string"
+    },
+    "io.k8s.api.core.v1.PodSpec": {
+      "type": "object",
+      "properties": {
+        "containers": {
+          "type": "array",
+          "items": {"$ref": "#/definitions/io.k8s.api.core.v1.Container"}
+        }
+      }
+    },
+    "io.k8s.api.core.v1.Container": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"}
+      }
+    }
+  }
+}`
+
+const BUNDLED_ITEMS_REFS = `
+# items:
+#   $ref: https://example.com/k8s/_definitions.json#/definitions/io.k8s.api.core.v1.PodSpec
+# ---
+# pod specs
+containers: []
+`
+
+func TestGenerateBundledRefs(t *testing.T) {
+	chartDir := writeChart(t, BUNDLED_REFS)
+	require.NoError(t, os.MkdirAll(filepath.Join(chartDir, "schemas"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "schemas", "probe.json"), []byte(PROBE_SCHEMA), 0644))
+
+	t.Run("refs are bundled from files and the cache", func(tt *testing.T) {
+		cacheDir := tt.TempDir()
+		require.NoError(tt, os.MkdirAll(filepath.Join(cacheDir, "example.com", "k8s"), 0755))
+		require.NoError(tt, os.WriteFile(filepath.Join(cacheDir, "example.com", "k8s", "_definitions.json"), []byte(K8S_DEFINITIONS), 0644))
+
+		s, err := generateChart(tt, chartDir, &Config{Strict: true, BundleRefs: true, RefCacheDir: cacheDir})
+		require.NoError(tt, err)
+
+		assert.Equal(tt, "#/definitions/probe", property(tt, s, "livenessProbe").Ref)
+		assert.Equal(tt, "#/definitions/io.k8s.api.core.v1.ResourceRequirements", property(tt, s, "resources").Ref)
+		assert.Equal(tt, []string{
+			"probe",
+			"io.k8s.api.core.v1.ResourceRequirements",
+			"io.k8s.apimachinery.pkg.api.resource.Quantity",
+		}, slices.Collect(s.Definitions.Keys()))
+
+		requirements, _ := s.Definitions.Get("io.k8s.api.core.v1.ResourceRequirements")
+		assert.Equal(tt, "#/definitions/io.k8s.apimachinery.pkg.api.resource.Quantity", property(tt, requirements, "limits").Ref)
+	})
+
+	t.Run("refs under items are bundled", func(tt *testing.T) {
+		cacheDir := tt.TempDir()
+		require.NoError(tt, os.MkdirAll(filepath.Join(cacheDir, "example.com", "k8s"), 0755))
+		require.NoError(tt, os.WriteFile(filepath.Join(cacheDir, "example.com", "k8s", "_definitions.json"), []byte(K8S_DEFINITIONS), 0644))
+
+		itemsChartDir := writeChart(tt, BUNDLED_ITEMS_REFS)
+		s, err := generateChart(tt, itemsChartDir, &Config{Strict: true, BundleRefs: true, RefCacheDir: cacheDir})
+		require.NoError(tt, err)
+
+		containers, ok := property(tt, s, "containers").Items.(*pkg.JsonSchema)
+		require.True(tt, ok)
+		assert.Equal(tt, "#/definitions/io.k8s.api.core.v1.PodSpec", containers.Ref)
+
+		podSpec, _ := s.Definitions.Get("io.k8s.api.core.v1.PodSpec")
+		require.NotNil(tt, podSpec)
+		podContainers, ok := property(tt, podSpec, "containers").Items.(*pkg.JsonSchema)
+		require.True(tt, ok)
+		assert.Equal(tt, "#/definitions/io.k8s.api.core.v1.Container", podContainers.Ref)
+		assert.True(tt, s.Definitions.Has("io.k8s.api.core.v1.Container"))
+	})
+
+	t.Run("refs missing from the cache fail", func(tt *testing.T) {
+		_, err := generateChart(tt, chartDir, &Config{Strict: true, BundleRefs: true, RefCacheDir: tt.TempDir()})
+		assert.ErrorContains(tt, err, "cannot resolve $ref https://example.com/k8s/_definitions.json")
+	})
+
+	t.Run("unresolvable refs fail the command", func(tt *testing.T) {
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+
+		err := GenerateSchema(logger, &Config{Strict: true, BundleRefs: true, RefCacheDir: tt.TempDir()}, []string{chartDir})
+		assert.ErrorContains(tt, err, "test-chart: ")
+		assert.ErrorContains(tt, err, "cannot resolve $ref https://example.com/k8s/_definitions.json")
+		assert.NoFileExists(tt, filepath.Join(chartDir, "values.schema.json"))
+	})
+}
+
+func TestGenerateAdditionalProperties(t *testing.T) {
+	tests := []struct {
+		policy   AdditionalPropertiesPolicy
+		expected map[string]any
+	}{
+		{
+			policy: "",
+			expected: map[string]any{
+				"image": false, "podAnnotations": true, "extraConfig": nil, "extraConfig.nested": nil, "labels": true, "probe": nil,
+			},
+		},
+		{
+			policy: AdditionalPropertiesStrict,
+			expected: map[string]any{
+				"image": false, "podAnnotations": false, "extraConfig": nil, "extraConfig.nested": nil, "labels": true, "probe": nil,
+			},
+		},
+		{
+			policy: AdditionalPropertiesLax,
+			expected: map[string]any{
+				"image": nil, "podAnnotations": nil, "extraConfig": nil, "extraConfig.nested": nil, "labels": true, "probe": nil,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy)+" policy", func(tt *testing.T) {
+			s, err := generateFromValuesWithConfig(tt, ADDITIONAL_PROPERTIES, &Config{Strict: true, AdditionalProperties: test.policy})
+			require.NoError(tt, err)
+
+			assert.Nil(tt, s.AdditionalProperties)
+			for path, expected := range test.expected {
+				prop := s
+				for _, key := range strings.Split(path, ".") {
+					prop = property(tt, prop, key)
+				}
+				assert.Equal(tt, expected, prop.AdditionalProperties, path)
+			}
+		})
+	}
+
+	t.Run("invalid policy annotation", func(tt *testing.T) {
+		_, err := generateFromValuesWithConfig(tt, "# additionalPropertiesPolicy: open\nfoo:\n  bar: 1\n", &Config{Strict: true})
+		assert.ErrorContains(tt, err, "invalid additional properties policy: open")
+	})
+}
+
+func TestGenerateKeywords(t *testing.T) {
+	t.Run("unknown keywords are dropped", func(tt *testing.T) {
+		s, err := generateFromValuesWithConfig(tt, UNKNOWN_KEYWORDS, &Config{})
+		require.NoError(tt, err)
+
+		name := property(tt, s, "name")
+		assert.Equal(tt, lo.ToPtr(int64(3)), name.MinLength)
+
+		data, err := json.Marshal(name)
+		require.NoError(tt, err)
+		assert.JSONEq(tt, `{
+			"type": "string",
+			"title": "name",
+			"default": "foo",
+			"minLength": 3,
+			"$comment": "keep this short"
+		}`, string(data))
+	})
+
+	t.Run("unknown keywords fail in strict mode", func(tt *testing.T) {
+		_, err := generateFromValuesWithConfig(tt, UNKNOWN_KEYWORDS, &Config{Strict: true})
+		assert.ErrorContains(tt, err, "unknown schema keyword: constant")
+	})
+}
+
+func TestGenerateNumericConstraints(t *testing.T) {
+	t.Run("zero and fractional values are kept", func(tt *testing.T) {
+		s, err := generateFromValues(tt, NUMERIC_CONSTRAINTS)
+		require.NoError(tt, err)
+
+		data, err := json.Marshal(property(tt, s, "cpu"))
+		require.NoError(tt, err)
+		assert.JSONEq(tt, `{
+			"type": "number",
+			"title": "cpu",
+			"description": "cpu request",
+			"default": 0.5,
+			"minimum": 0,
+			"multipleOf": 0.25
+		}`, string(data))
+
+		data, err = json.Marshal(property(tt, s, "nameOverride"))
+		require.NoError(tt, err)
+		assert.Contains(tt, string(data), `"minLength":0`)
+	})
+
+	t.Run("exclusive limits use the draft-06 form", func(tt *testing.T) {
+		s, err := generateFromValues(tt, NUMERIC_CONSTRAINTS)
+		require.NoError(tt, err)
+
+		replicas := property(tt, s, "replicas")
+		assert.Equal(tt, pkg.Number(""), replicas.Minimum)
+		assert.Equal(tt, pkg.ExclusiveLimit("1"), replicas.ExclusiveMinimum)
+		assert.Equal(tt, pkg.Number("10"), replicas.Maximum)
+		assert.Equal(tt, pkg.ExclusiveLimit("11"), replicas.ExclusiveMaximum)
+	})
+
+	t.Run("exclusive limits use the draft-04 form", func(tt *testing.T) {
+		values := "# $schema: http://json-schema.org/draft-04/schema#\n# @root\n" + NUMERIC_CONSTRAINTS
+		s, err := generateFromValues(tt, values)
+		require.NoError(tt, err)
+
+		replicas := property(tt, s, "replicas")
+		assert.Equal(tt, pkg.Number("1"), replicas.Minimum)
+		assert.Equal(tt, pkg.NewExclusiveLimit(true), replicas.ExclusiveMinimum)
+		assert.Equal(tt, pkg.Number("11"), replicas.Maximum)
+		assert.Equal(tt, pkg.NewExclusiveLimit(true), replicas.ExclusiveMaximum)
+	})
+}
+
+func TestGeneratePatternProperties(t *testing.T) {
+	t.Run("pattern properties use the source pattern", func(tt *testing.T) {
+		s, err := generateFromValuesWithConfig(tt, PATTERN_PROPERTIES, &Config{Strict: true})
+		require.NoError(tt, err)
+
+		data, err := json.Marshal(property(tt, s, "podLabels"))
+		require.NoError(tt, err)
+		assert.JSONEq(tt, `{
+			"type": "object",
+			"title": "podLabels",
+			"description": "labels added to pods",
+			"default": {},
+			"additionalProperties": false,
+			"properties": {},
+			"patternProperties": {
+				"^[a-z0-9.-/]+$": {"type": "string", "maxLength": 63}
+			}
+		}`, string(data))
+	})
+
+	t.Run("incompatible patterns fail in strict mode", func(tt *testing.T) {
+		_, err := generateFromValuesWithConfig(tt, "# pattern: '(?i)^[a-z]+$'\nname: foo\n", &Config{Strict: true})
+		assert.ErrorContains(tt, err, "line 1: pattern is not ECMA-262 compatible: (?i)^[a-z]+$")
+	})
+}
+
+func TestGenerateExtensions(t *testing.T) {
+	s, err := generateFromValuesWithConfig(t, EXTENSIONS, &Config{Strict: true})
+	require.NoError(t, err)
+
+	password := property(t, s, "password")
+	assert.Equal(t, []string{"x-ui-widget", "x-sensitive", "x-group"}, slices.Collect(password.Extensions.Keys()))
+	assert.Equal(t, true, password.Extension("x-sensitive"))
+
+	data, err := json.Marshal(password)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"type":"string","title":"password","description":"database password","default":"",`+
+			`"x-ui-widget":"password","x-sensitive":true,"x-group":"credentials"}`,
+		string(data),
+	)
+}
+
+func TestGenerateRequiredValues(t *testing.T) {
+	chartDir := writeChart(t, REQUIRED_VALUES)
+	chart, err := charts.NewChart(chartDir)
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logOutput := &strings.Builder{}
+	logger.SetOutput(logOutput)
+
+	s, err := NewGenerator(logger, NewPlan(&Config{Strict: true}, chart)).Generate()
+	require.NoError(t, err)
+
+	database := property(t, s, "database")
+	assert.Equal(t, []string{"host", "port"}, database.Required)
+	assert.Equal(t, "database host", property(t, database, "host").Description)
+	assert.Equal(t, "database port", property(t, database, "port").Description)
+
+	assert.Contains(t, logOutput.String(), "value is required but has a default: database.port")
+	assert.NotContains(t, logOutput.String(), "has a default: database.host")
+}
+
+func TestGenerateRequiredDefinitionValues(t *testing.T) {
+	s, err := generateFromValuesWithConfig(t, REQUIRED_DEFINITION_VALUES, &Config{Strict: true, AnchorDefinitions: true})
+	require.NoError(t, err)
+
+	database := property(t, s, "database")
+	assert.Equal(t, []string{"primary", "replica", "standby", "backup"}, database.Required)
+	assert.Equal(t, "#/definitions/endpoint", property(t, database, "primary").Ref)
+	assert.Equal(t, "#/definitions/replica", property(t, database, "standby").Ref)
+}
+
+func TestGenerateHelmDocsComments(t *testing.T) {
+	s, err := generateFromValuesWithConfig(t, HELM_DOCS_COMMENTS, &Config{Strict: true, CommentDialect: comments.DialectHelmDocs})
+	require.NoError(t, err)
+
+	image := property(t, s, "image")
+	assert.Equal(t, "image repository pulled from docker hub", property(t, image, "repository").Description)
+	assert.Equal(t, "image tag, defaults to the chart appVersion", property(t, image, "tag").Description)
+
+	pullPolicy := property(t, image, "pullPolicy")
+	assert.Equal(t, "pull policy", pullPolicy.Description)
+	assert.Equal(t, "IfNotPresent", pullPolicy.DocsDefault)
+	assert.Equal(t, "Image", pullPolicy.DocsSection)
+
+	data, err := json.Marshal(pullPolicy)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "IfNotPresent")
+}
+
+func TestGenerateBitnamiComments(t *testing.T) {
+	chartDir := writeChart(t, BITNAMI_COMMENTS)
+	chart, err := charts.NewChart(chartDir)
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logOutput := &strings.Builder{}
+	logger.SetOutput(logOutput)
+
+	s, err := NewGenerator(logger, NewPlan(&Config{Strict: true, CommentDialect: comments.DialectBitnami}, chart)).Generate()
+	require.NoError(t, err)
+
+	nameOverride := property(t, s, "nameOverride")
+	assert.Equal(t, "Partially overrides the chart name", nameOverride.Description)
+	assert.Equal(t, "Common parameters", nameOverride.DocsSection)
+
+	manifests := property(t, property(t, s, "extraDeploy"), "manifests")
+	assert.Equal(t, pkg.NewSchemaType("array"), manifests.Type)
+	assert.Equal(t, "Extra objects to deploy", manifests.Description)
+
+	assert.True(t, property(t, s, "diagnosticMode").DocsIgnored)
+	assert.NotContains(t, logOutput.String(), "value is undocumented: diagnosticMode")
+}
+
+func TestGenerateSchemaFences(t *testing.T) {
+	s, err := generateFromValuesWithConfig(t, SCHEMA_FENCES, &Config{Strict: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"podLabels"}, s.Required)
+
+	podLabels := property(t, s, "podLabels")
+	assert.Equal(t, "Pod labels", podLabels.Description)
+
+	selector := property(t, podLabels, "selector")
+	assert.Equal(t, "Free-form selector", selector.Description)
+	assert.Nil(t, selector.Properties)
+	assert.Nil(t, selector.AdditionalProperties)
+	assert.Equal(t, map[string]any{"app": "demo"}, selector.Default)
+}
+
+func TestGenerateTrailingComments(t *testing.T) {
+	var tests = []struct {
+		name         string
+		footComments bool
+		validate     func(tt *testing.T, s *pkg.JsonSchema, logs string)
+	}{
+		{
+			name: "line comments describe values without a head comment",
+			validate: func(tt *testing.T, s *pkg.JsonSchema, logs string) {
+				assert.Equal(tt, "container port", property(tt, s, "port").Description)
+				assert.Equal(tt, "primary host", property(tt, s, "hosts").Items.(*pkg.JsonSchema).Description)
+				assert.Contains(tt, logs, "port: description read from line comment")
+			},
+		},
+		{
+			name: "head comments take precedence over line comments",
+			validate: func(tt *testing.T, s *pkg.JsonSchema, logs string) {
+				assert.Equal(tt, "Service settings", property(tt, s, "service").Description)
+				assert.Contains(tt, logs, "service: head comment takes precedence over line comment")
+			},
+		},
+		{
+			name: "foot comments are ignored by default",
+			validate: func(tt *testing.T, s *pkg.JsonSchema, logs string) {
+				assert.Equal(tt, "", property(tt, property(tt, s, "service"), "type").Description)
+				assert.Contains(tt, logs, "service.type: foot comment ignored")
+			},
+		},
+		{
+			name:         "foot comments describe the value above them",
+			footComments: true,
+			validate: func(tt *testing.T, s *pkg.JsonSchema, logs string) {
+				assert.Equal(tt, "Only used by LoadBalancer services", property(tt, property(tt, s, "service"), "type").Description)
+				assert.Contains(tt, logs, "service.type: description read from foot comment")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(tt *testing.T) {
+			chartDir := writeChart(tt, TRAILING_COMMENTS)
+			chart, err := charts.NewChart(chartDir)
+			require.NoError(tt, err)
+
+			logger := logrus.New()
+			logger.SetLevel(logrus.DebugLevel)
+			logOutput := &strings.Builder{}
+			logger.SetOutput(logOutput)
+
+			s, err := NewGenerator(logger, NewPlan(&Config{Strict: true, FootComments: tc.footComments}, chart)).Generate()
+			require.NoError(tt, err)
+			tc.validate(tt, s, logOutput.String())
+		})
+	}
+}
+
+func TestGenerateSubcharts(t *testing.T) {
+	chartDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(UMBRELLA_CHART_YAML), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte(UMBRELLA_VALUES), 0644))
+
+	redisDir := filepath.Join(chartDir, "charts", "redis")
+	require.NoError(t, os.MkdirAll(redisDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(redisDir, "Chart.yaml"), []byte(REDIS_CHART_YAML), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(redisDir, "values.yaml"), []byte(REDIS_VALUES), 0644))
+
+	writeChartArchive(t, filepath.Join(chartDir, "charts", "postgres-2.0.0.tgz"), map[string]string{
+		"postgres/Chart.yaml":  POSTGRES_CHART_YAML,
+		"postgres/values.yaml": POSTGRES_VALUES,
+	})
+
+	t.Run("dependencies are composed", func(tt *testing.T) {
+		s, err := generateChart(tt, chartDir, &Config{Strict: true, ComposeSubcharts: true, AnchorDefinitions: true})
+		require.NoError(tt, err)
+
+		assert.Equal(tt, []string{"global", "redis", "db", "tags"}, keys(s))
+
+		redis := property(tt, s, "redis")
+		assert.Equal(tt, []string{"enabled", "replicas", "auth", "global"}, keys(redis))
+		assert.Equal(tt, "number of redis replicas", property(tt, redis, "replicas").Description)
+		assert.Equal(tt, 3, property(tt, redis, "replicas").Default)
+		assert.Equal(tt, "redis user", property(tt, property(tt, redis, "auth"), "username").Description)
+		assert.Equal(tt, map[string]any{"username": "default"}, property(tt, redis, "auth").Default)
+
+		db := property(tt, s, "db")
+		assert.Equal(tt, "db", db.Title)
+		assert.Equal(tt, []string{"port", "replicaPort", "enabled"}, keys(db))
+		assert.Equal(tt, "#/definitions/db.port", property(tt, db, "port").Ref)
+		assert.True(tt, s.Definitions.Has("db.port"))
+		assert.Equal(tt, pkg.NewSchemaType("boolean"), property(tt, db, "enabled").Type)
+
+		global := property(tt, s, "global")
+		assert.Equal(tt, []string{"imageRegistry", "domain", "db"}, keys(global))
+		assert.Equal(tt, pkg.NewSchemaType("boolean"), property(tt, property(tt, global, "db"), "enabled").Type)
+
+		storage := property(tt, property(tt, s, "tags"), "storage")
+		assert.Equal(tt, pkg.NewSchemaType("boolean"), storage.Type)
+	})
+
+	t.Run("composition can be disabled", func(tt *testing.T) {
+		s, err := generateChart(tt, chartDir, &Config{Strict: true})
+		require.NoError(tt, err)
+		assert.Equal(tt, []string{"global", "redis"}, keys(s))
+	})
+}
+
+func TestGenerateSchemaCheck(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	chartDir := writeChart(t, "# the name\nname: foo\n")
+	cfg := &Config{Strict: true, WriteModeline: true}
+
+	t.Run("missing files are out of date", func(tt *testing.T) {
+		err := GenerateSchema(logger, &Config{Strict: true, WriteModeline: true, Check: true}, []string{chartDir})
+		assert.ErrorIs(tt, err, ErrOutOfDate)
+		assert.NoFileExists(tt, filepath.Join(chartDir, "values.schema.json"))
+	})
+
+	t.Run("generated files are up to date", func(tt *testing.T) {
+		require.NoError(tt, GenerateSchema(logger, cfg, []string{chartDir}))
+		assert.NoError(tt, GenerateSchema(logger, &Config{Strict: true, WriteModeline: true, Check: true}, []string{chartDir}))
+	})
+
+	t.Run("values changes and missing modelines are out of date", func(tt *testing.T) {
+		require.NoError(tt, os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte("# the name\nname: bar\n"), 0644))
+
+		chart, err := charts.NewChart(chartDir)
+		require.NoError(tt, err)
+		plan := NewPlan(&Config{Strict: true, WriteModeline: true, Check: true}, chart)
+		s, err := NewGenerator(logger, plan).Generate()
+		require.NoError(tt, err)
+
+		outdated, err := plan.CheckSchema(s)
+		require.NoError(tt, err)
+		assert.Equal(tt, []string{chart.SchemaFilePath(), chart.ValuesFilePath()}, outdated)
+
+		content, err := os.ReadFile(chart.ValuesFilePath())
+		require.NoError(tt, err)
+		assert.Equal(tt, "# the name\nname: bar\n", string(content))
+	})
+
+	t.Run("failing charts fail the check", func(tt *testing.T) {
+		outdatedDir := writeChart(tt, "# the name\nname: foo\n")
+		failingDir := writeChart(tt, "# pattern: (?i)foo\n# ---\n# the name\nname: foo\n")
+
+		err := GenerateSchema(logger, &Config{Strict: true, Check: true}, []string{outdatedDir, failingDir})
+		assert.ErrorIs(tt, err, ErrOutOfDate)
+		assert.ErrorIs(tt, err, ErrGenerateFailed)
+
+		err = GenerateSchema(logger, &Config{Strict: true, Check: true}, []string{failingDir, outdatedDir})
+		assert.ErrorIs(tt, err, ErrOutOfDate)
+		assert.ErrorIs(tt, err, ErrGenerateFailed)
+	})
+}
+
+func generateFromValues(t *testing.T, values string) (*pkg.JsonSchema, error) {
+	return generateFromValuesWithConfig(t, values, &Config{Strict: true})
+}
+
+func generateFromValuesWithConfig(t *testing.T, values string, cfg *Config) (*pkg.JsonSchema, error) {
+	return generateChart(t, writeChart(t, values), cfg)
+}
+
+func writeChart(t *testing.T, values string) string {
+	chartDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(TEST_CHART_YAML), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "values.yaml"), []byte(values), 0644))
+	return chartDir
+}
+
+func writeChartArchive(t *testing.T, archivePath string, files map[string]string) {
+	f, err := os.Create(archivePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		content := []byte(files[name])
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func generateChart(t *testing.T, chartDir string, cfg *Config) (*pkg.JsonSchema, error) {
+	chart, err := charts.NewChart(chartDir)
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	plan := NewPlan(cfg, chart)
+	return NewGenerator(logger, plan).Generate()
+}
+
+func property(t *testing.T, s *pkg.JsonSchema, key string) *pkg.JsonSchema {
+	prop, ok := s.Properties.Get(key)
+	require.True(t, ok, "property %s not found", key)
+	return prop
+}
+
+func keys(s *pkg.JsonSchema) []string {
+	keys := []string{}
+	for k := range s.Properties.Keys() {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -3,7 +3,6 @@ package schema
 import (
 	"fmt"
 	"helmvalues/internal/charts"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -31,32 +30,29 @@ func WriteSchemaModeline(logger *logrus.Logger, chart *charts.Chart, dryRun bool
 		return nil
 	}
 
-	f, err := os.OpenFile(valuesFilePath, os.O_RDONLY, 0644)
+	content, err := os.ReadFile(valuesFilePath)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	contentB, err := io.ReadAll(f)
+	updatedContent := withSchemaModeline(string(content), chart.SchemaFilePath())
+	err = os.WriteFile(valuesFilePath, []byte(updatedContent), 0644)
 	if err != nil {
 		return err
 	}
-	content := string(contentB)
-	var updatedContent string
 
+	return nil
+}
+
+// withSchemaModeline returns the values file content with the modeline
+// inserted, or replaced if the content already has one.
+func withSchemaModeline(content string, schemaPath string) string {
 	modelineStart := strings.Index(content, fmt.Sprintf("# %s:", YAML_MODELINE))
 	if modelineStart == -1 {
 		// write an extra newline when inserting the modeline for the first time
-		updatedContent = renderedModeline(chart.SchemaFilePath()) + "\n" + content
-	} else {
-		eolIdx := strings.Index(content[modelineStart:], "\n")
-		updatedContent = content[:modelineStart] + renderedModeline(chart.SchemaFilePath()) + content[modelineStart+eolIdx+1:]
-	}
-
-	err = os.WriteFile(valuesFilePath, []byte(updatedContent), 0644)
-	if err != nil {
-		return err
+		return renderedModeline(schemaPath) + "\n" + content
 	}
 
-	return nil
+	eolIdx := strings.Index(content[modelineStart:], "\n")
+	return content[:modelineStart] + renderedModeline(schemaPath) + content[modelineStart+eolIdx+1:]
 }
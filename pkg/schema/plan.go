@@ -3,11 +3,14 @@ package schema
 import (
 	"encoding/json"
 	"fmt"
+	"helmvalues/internal"
 	"helmvalues/internal/charts"
 	"helmvalues/pkg"
+	"helmvalues/pkg/schema/comments"
 	"os"
 
 	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
 )
 
 func NewPlan(cfg *Config, chart *charts.Chart) *Plan {
@@ -25,6 +28,9 @@ type Plan struct {
 func (p *Plan) LogCommonDetails(logger *logrus.Logger) {
 	// common configs
 	logger.Debugf("plan: %s: DryRun=%t", p.chart.Details.Name, p.DryRun())
+	logger.Debugf("plan: %s: Check=%t", p.chart.Details.Name, p.Check())
+	logger.Debugf("plan: %s: Diff=%t", p.chart.Details.Name, p.Diff())
+	logger.Debugf("plan: %s: Color=%t", p.chart.Details.Name, p.Color())
 	logger.Debugf("plan: %s: StrictComments=%t", p.chart.Details.Name, p.StrictComments())
 	logger.Debugf("plan: %s: Stdout=%t", p.chart.Details.Name, p.StdOut())
 }
@@ -35,11 +41,21 @@ func (p *Plan) LogChartDetails(logger *logrus.Logger) {
 	logger.Debugf("plan: %s: ChartFile=%s", p.chart.Details.Name, p.chart.ChartFilePath())
 	logger.Debugf("plan: %s: ChartValuesFile=%s", p.chart.Details.Name, p.chart.ValuesFilePath())
 	logger.Debugf("plan: %s: ChartSchemaFile=%s", p.chart.Details.Name, p.chart.SchemaFilePath())
+	logger.Debugf("plan: %s: ChartDefinitionsFile=%s", p.chart.Details.Name, p.chart.DefinitionsFilePath())
 	// logger.Debugf("plan: %s: ChartReadmeTemplate=%s", p.chart.Details.Name, p.DocsChartReadmeTemplate())
 }
 
 func (p *Plan) LogSchemaDetails(logger *logrus.Logger) {
 	logger.Debugf("plan: %s: WriteModeline=%t", p.chart.Details.Name, p.cfg.WriteModeline)
+	logger.Debugf("plan: %s: AnchorDefinitions=%t", p.chart.Details.Name, p.cfg.AnchorDefinitions)
+	logger.Debugf("plan: %s: TagSchemas=%d", p.chart.Details.Name, len(p.cfg.TagSchemas))
+	logger.Debugf("plan: %s: BundleRefs=%t", p.chart.Details.Name, p.cfg.BundleRefs)
+	logger.Debugf("plan: %s: RefCacheDir=%s", p.chart.Details.Name, p.cfg.RefCacheDir)
+	logger.Debugf("plan: %s: ComposeSubcharts=%t", p.chart.Details.Name, p.cfg.ComposeSubcharts)
+	logger.Debugf("plan: %s: AdditionalProperties=%s", p.chart.Details.Name, p.AdditionalProperties())
+	logger.Debugf("plan: %s: CommentDialect=%s", p.chart.Details.Name, p.CommentDialect())
+	logger.Debugf("plan: %s: FootComments=%t", p.chart.Details.Name, p.FootComments())
+	logger.Debugf("plan: %s: DetachedMarker=%s", p.chart.Details.Name, p.DetachedMarker())
 }
 
 func (p *Plan) Chart() *charts.Chart {
@@ -58,6 +74,71 @@ func (p *Plan) DryRun() bool {
 	return p.cfg.DryRun
 }
 
+func (p *Plan) Check() bool {
+	return p.cfg.Check
+}
+
+func (p *Plan) Diff() bool {
+	return p.cfg.Diff
+}
+
+func (p *Plan) Color() bool {
+	return p.cfg.Color
+}
+
+func (p *Plan) AnchorDefinitions() bool {
+	return p.cfg.AnchorDefinitions
+}
+
+func (p *Plan) BundleRefs() bool {
+	return p.cfg.BundleRefs
+}
+
+func (p *Plan) RefCacheDir() string {
+	return p.cfg.RefCacheDir
+}
+
+func (p *Plan) ComposeSubcharts() bool {
+	return p.cfg.ComposeSubcharts
+}
+
+// AdditionalProperties returns the chart-wide additionalProperties policy,
+// defaulting to strict-except-empty.
+func (p *Plan) AdditionalProperties() AdditionalPropertiesPolicy {
+	if p.cfg.AdditionalProperties == "" {
+		return AdditionalPropertiesStrictExceptEmpty
+	}
+	return p.cfg.AdditionalProperties
+}
+
+// CommentDialect returns the syntax of the chart's doc comments, defaulting
+// to the native syntax.
+func (p *Plan) CommentDialect() comments.Dialect {
+	if p.cfg.CommentDialect == "" {
+		return comments.DialectNative
+	}
+	return p.cfg.CommentDialect
+}
+
+func (p *Plan) FootComments() bool {
+	return p.cfg.FootComments
+}
+
+// DetachedMarker returns the marker starting comment paragraphs that aren't
+// part of the doc comment below them.
+func (p *Plan) DetachedMarker() string {
+	if p.cfg.DetachedMarker == "" {
+		return comments.DefaultDetachedMarker
+	}
+	return p.cfg.DetachedMarker
+}
+
+// TagSchema returns the schema mapping node configured for the yaml tag.
+func (p *Plan) TagSchema(tag string) (*yaml.Node, bool) {
+	node, ok := p.cfg.TagSchemas[tag]
+	return node, ok
+}
+
 func (p *Plan) WriteSchema(logger *logrus.Logger, schema *pkg.JsonSchema) error {
 	s, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
@@ -85,3 +166,50 @@ func (p *Plan) WriteSchema(logger *logrus.Logger, schema *pkg.JsonSchema) error
 
 	return nil
 }
+
+// SchemaChanges returns the files the schema command writes, with their
+// content on disk and the content they would be written with.
+func (p *Plan) SchemaChanges(schema *pkg.JsonSchema) ([]internal.FileChange, error) {
+	s, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	schemaChange, err := internal.NewFileChange(p.chart.SchemaFilePath(), string(s))
+	if err != nil {
+		return nil, err
+	}
+	changes := []internal.FileChange{schemaChange}
+
+	if p.cfg.WriteModeline {
+		content, err := os.ReadFile(p.chart.ValuesFilePath())
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, internal.FileChange{
+			Path:   p.chart.ValuesFilePath(),
+			Exists: true,
+			Before: string(content),
+			After:  withSchemaModeline(string(content), p.chart.SchemaFilePath()),
+		})
+	}
+
+	return changes, nil
+}
+
+// CheckSchema returns the files of the chart which differ from what the schema
+// command would write, without writing them.
+func (p *Plan) CheckSchema(schema *pkg.JsonSchema) ([]string, error) {
+	changes, err := p.SchemaChanges(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	outdated := []string{}
+	for _, change := range changes {
+		if change.Changed() {
+			outdated = append(outdated, change.Path)
+		}
+	}
+	return outdated, nil
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// RootMarker separates the root schema comment from the doc comment of the
+// first key in the values file.
+const RootMarker = "# @root"
+
+// rootCommentNode returns a node holding the doc comment of the root schema,
+// or nil if there isn't one. The root comment is the part of the first key's
+// head comment above the root marker, otherwise the part of the values
+// document head comment above the root marker. Without a marker the document
+// head comment is left alone, since it is usually a license or banner. The
+// first key's head comment is trimmed to the part below the root marker.
+func rootCommentNode(document *yaml.Node) *yaml.Node {
+	if len(document.Content) > 0 && len(document.Content[0].Content) > 0 {
+		firstKey := document.Content[0].Content[0]
+		lines := strings.Split(firstKey.HeadComment, "\n")
+
+		if idx := slices.Index(lines, RootMarker); idx != -1 {
+			keyLines := lines[idx+1:]
+			firstKey.HeadComment = strings.Join(keyLines, "\n")
+
+			return &yaml.Node{
+				Kind:        yaml.ScalarNode,
+				HeadComment: strings.Join(lines[:idx], "\n"),
+				Line:        firstKey.Line - len(keyLines) - 1,
+			}
+		}
+	}
+
+	if document.HeadComment == "" {
+		return nil
+	}
+
+	lines := strings.Split(document.HeadComment, "\n")
+	idx := slices.Index(lines, RootMarker)
+	if idx == -1 {
+		return nil
+	}
+
+	// The modeline is not part of the root comment
+	comment := strings.Join(
+		slices.DeleteFunc(slices.Clone(lines[:idx]), func(line string) bool {
+			return strings.HasPrefix(line, fmt.Sprintf("# %s:", YAML_MODELINE))
+		}),
+		"\n",
+	)
+	comment = strings.Trim(comment, "\n")
+	if comment == "" {
+		return nil
+	}
+
+	return &yaml.Node{
+		Kind:        yaml.ScalarNode,
+		HeadComment: comment,
+		Line:        idx + 1,
+	}
+}
@@ -1,11 +1,25 @@
 package schema
 
 import (
+	"errors"
+	"fmt"
+	"helmvalues/internal"
 	"helmvalues/internal/charts"
+	"helmvalues/pkg"
+	"os"
 
 	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
 )
 
+// ErrOutOfDate is returned in check mode when files on disk differ from the
+// generated files.
+var ErrOutOfDate = errors.New("files are out of date")
+
+// ErrGenerateFailed is returned in check mode when charts fail to generate,
+// after the remaining charts have been checked.
+var ErrGenerateFailed = errors.New("charts failed to generate")
+
 func GenerateSchema(logger *logrus.Logger, cfg *Config, chartDirs []string) error {
 	chartsFound, err := charts.Search(logger, chartDirs)
 	if err != nil {
@@ -24,12 +38,43 @@ func GenerateSchema(logger *logrus.Logger, cfg *Config, chartDirs []string) erro
 	}
 
 	// Iterate through plans again, this time generating the schema
+	outdated, failed := 0, 0
 	for _, plan := range plans {
 		logger.Infof("schema: %s: starting generation", plan.Chart().Details.Name)
 		schema, err := NewGenerator(logger, plan).Generate()
 		if err != nil {
-			logger.Error(err.Error())
-			return nil
+			if plan.Check() {
+				logger.Error(err.Error())
+				failed++
+				continue
+			}
+			return fmt.Errorf("%s: %w", plan.Chart().Details.Name, err)
+		}
+
+		if plan.Diff() {
+			logger.Debugf("schema: %s: printing diff", plan.Chart().Details.Name)
+			changes, err := plan.SchemaChanges(schema)
+			if err != nil {
+				return err
+			}
+			if err := internal.WriteDiffs(os.Stdout, plan.Chart().Details.Name, changes, plan.Color()); err != nil {
+				return err
+			}
+		}
+
+		if plan.Check() {
+			logger.Debugf("schema: %s: checking output", plan.Chart().Details.Name)
+			paths, err := plan.CheckSchema(schema)
+			if err != nil {
+				return err
+			}
+			for _, path := range paths {
+				fmt.Printf("%s: %s is out of date\n", plan.Chart().Details.Name, path)
+			}
+			outdated += len(paths)
+
+			logger.Infof("schema: %s: finished", plan.Chart().Details.Name)
+			continue
 		}
 
 		logger.Debugf("schema: %s: writing output", plan.Chart().Details.Name)
@@ -51,5 +96,39 @@ func GenerateSchema(logger *logrus.Logger, cfg *Config, chartDirs []string) erro
 		logger.Infof("schema: %s: finished", plan.Chart().Details.Name)
 	}
 
-	return nil
+	return CheckResult(outdated, failed)
+}
+
+// CheckResult returns the error of check mode for the number of out of date
+// files and failed charts, or nil if there are neither.
+func CheckResult(outdated int, failed int) error {
+	errs := []error{}
+	if outdated > 0 {
+		errs = append(errs, fmt.Errorf("%w (%d)", ErrOutOfDate, outdated))
+	}
+	if failed > 0 {
+		errs = append(errs, fmt.Errorf("%w (%d)", ErrGenerateFailed, failed))
+	}
+	return errors.Join(errs...)
+}
+
+// LoadSchema returns the chart's schema from the source. Generated schemas use
+// the config, and are never written.
+func LoadSchema(logger *logrus.Logger, source Source, cfg *Config, chart *charts.Chart) (*pkg.JsonSchema, error) {
+	if source == SourceGenerate {
+		logger.Debugf("schema: %s: generating schema", chart.Details.Name)
+		return NewGenerator(logger, NewPlan(cfg, chart)).Generate()
+	}
+
+	logger.Debugf("schema: %s: reading schema %s", chart.Details.Name, chart.SchemaFilePath())
+	content, err := os.ReadFile(chart.SchemaFilePath())
+	if err != nil {
+		return nil, err
+	}
+
+	jsonschema := &pkg.JsonSchema{}
+	if err := yaml.Unmarshal(content, jsonschema); err != nil {
+		return nil, fmt.Errorf("%s: %w", chart.SchemaFilePath(), err)
+	}
+	return jsonschema, nil
 }
@@ -0,0 +1,215 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"helmvalues/internal/charts"
+	"helmvalues/pkg"
+	"strings"
+)
+
+const GlobalValuesKey = "global"
+const TagsValuesKey = "tags"
+
+// composeSubcharts embeds the schema of each of the chart's dependencies under
+// the dependency's values key. The parent chart's own schema for that key is
+// applied on top, so its descriptions and overriding defaults win. Global
+// values declared by subcharts are merged into the parent's global values, and
+// the dependency conditions and tags are added where missing.
+func (g *Generator) composeSubcharts(root *pkg.JsonSchema) error {
+	globals := []*pkg.JsonSchema{}
+
+	for _, dep := range g.plan.chart.Details.Dependencies {
+		subSchema, err := g.generateSubchart(dep)
+		if errors.Is(err, charts.ErrSubchartNotFound) {
+			g.logger.Warnf(
+				"schema: %s: dependency %s not found in %s, run helm dependency build to include its schema",
+				g.plan.chart.Details.Name,
+				dep.Name,
+				g.plan.chart.SubchartsDirPath(),
+			)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("dependency %s: %w", dep.Name, err)
+		}
+
+		key := dep.ValuesKey()
+		g.liftDefinitions(root, subSchema, key)
+
+		if global, ok := subSchema.Properties.Get(GlobalValuesKey); ok {
+			globals = append(globals, global)
+		}
+
+		subSchema.Schema = ""
+		subSchema.ID = ""
+		subSchema.Title = key
+		subSchema.Type = pkg.NewSchemaType("object")
+
+		if parentSchema, ok := root.Properties.Get(key); ok {
+			subSchema = overlaySchema(subSchema, parentSchema)
+		}
+		root.Properties.Set(key, subSchema)
+
+		for _, condition := range dep.Conditions() {
+			ensureBooleanValue(root, strings.Split(condition, "."), fmt.Sprintf("Enables the %s dependency", key))
+		}
+		for _, tag := range dep.Tags {
+			ensureBooleanValue(root, []string{TagsValuesKey, tag}, fmt.Sprintf("Enables dependencies tagged %s", tag))
+		}
+	}
+
+	if len(globals) > 0 {
+		global := &pkg.JsonSchema{
+			Type:       pkg.NewSchemaType("object"),
+			Title:      GlobalValuesKey,
+			Properties: pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema](),
+		}
+		for _, subGlobal := range globals {
+			global = overlaySchema(global, subGlobal)
+		}
+		if parentGlobal, ok := root.Properties.Get(GlobalValuesKey); ok {
+			global = overlaySchema(global, parentGlobal)
+		}
+		root.Properties.Set(GlobalValuesKey, global)
+	}
+
+	return nil
+}
+
+// generateSubchart locates the dependency and generates its schema with the
+// same configuration as the parent chart.
+func (g *Generator) generateSubchart(dep *charts.ChartDependency) (*pkg.JsonSchema, error) {
+	subchart, cleanup, err := g.plan.chart.LoadSubchart(dep)
+	defer cleanup()
+	if err != nil {
+		return nil, err
+	}
+
+	g.logger.Debugf("schema: %s: generating subchart %s for %s", g.plan.chart.Details.Name, subchart.Details.Name, dep.ValuesKey())
+	return NewGenerator(g.logger, NewPlan(g.plan.cfg, subchart)).Generate()
+}
+
+// liftDefinitions moves the subchart's definitions into the root schema,
+// prefixed with the values key, and rewrites the subchart's refs to match.
+func (g *Generator) liftDefinitions(root *pkg.JsonSchema, subSchema *pkg.JsonSchema, key string) {
+	renamed := map[string]string{}
+	for prefix, section := range map[string]*pkg.EncodableOrderedMap[string, *pkg.JsonSchema]{
+		"#/definitions/": subSchema.Definitions,
+		"#/$defs/":       subSchema.Defs,
+	} {
+		if section == nil {
+			continue
+		}
+		for name := range section.Keys() {
+			oldRef := prefix + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+			renamed[oldRef] = definitionRef(key + "." + name)
+		}
+	}
+
+	subSchema.Walk(func(s *pkg.JsonSchema) {
+		if newRef, ok := renamed[s.Ref]; ok {
+			s.Ref = newRef
+		}
+	})
+
+	for _, section := range []*pkg.EncodableOrderedMap[string, *pkg.JsonSchema]{subSchema.Definitions, subSchema.Defs} {
+		if section == nil {
+			continue
+		}
+		if root.Definitions == nil {
+			root.Definitions = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+		}
+		for name, definition := range section.AllFromFront() {
+			root.Definitions.Set(key+"."+name, definition)
+		}
+	}
+
+	subSchema.Definitions = nil
+	subSchema.Defs = nil
+}
+
+// overlaySchema applies the overlay schema on top of the base schema. Object
+// properties are overlaid recursively, descriptions from the overlay win, and
+// default values are coalesced the way helm coalesces values.
+func overlaySchema(base *pkg.JsonSchema, overlay *pkg.JsonSchema) *pkg.JsonSchema {
+	merged := *base
+	if overlay.Title != "" {
+		merged.Title = overlay.Title
+	}
+	if overlay.Description != "" {
+		merged.Description = overlay.Description
+	}
+	if len(merged.Type) == 0 {
+		merged.Type = overlay.Type
+	}
+	if merged.Items == nil {
+		merged.Items = overlay.Items
+	}
+	merged.Default = coalesceValues(base.Default, overlay.Default)
+
+	if overlay.Properties != nil {
+		merged.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+		if base.Properties != nil {
+			for k, v := range base.Properties.AllFromFront() {
+				merged.Properties.Set(k, v)
+			}
+		}
+		for k, v := range overlay.Properties.AllFromFront() {
+			if existing, ok := merged.Properties.Get(k); ok {
+				v = overlaySchema(existing, v)
+			}
+			merged.Properties.Set(k, v)
+		}
+	}
+
+	return &merged
+}
+
+// coalesceValues merges the overlay value onto the base value. Maps are merged
+// recursively, and a null in an overlay map deletes the key.
+func coalesceValues(base any, overlay any) any {
+	baseMap, baseOk := base.(map[string]any)
+	overlayMap, overlayOk := overlay.(map[string]any)
+	if !baseOk || !overlayOk {
+		if overlay == nil {
+			return base
+		}
+		return overlay
+	}
+
+	merged := map[string]any{}
+	for k, v := range baseMap {
+		merged[k] = v
+	}
+	for k, v := range overlayMap {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = coalesceValues(merged[k], v)
+	}
+	return merged
+}
+
+// ensureBooleanValue adds a boolean property at the value path if nothing is
+// declared there, creating intermediate objects as needed.
+func ensureBooleanValue(root *pkg.JsonSchema, path []string, description string) {
+	s := root
+	for i, key := range path {
+		if s.Properties == nil {
+			s.Properties = pkg.NewEncodableOrderedMap[string, *pkg.JsonSchema]()
+		}
+
+		child, ok := s.Properties.Get(key)
+		if !ok {
+			child = &pkg.JsonSchema{Title: key, Type: pkg.NewSchemaType("object")}
+			if i == len(path)-1 {
+				child.Type = pkg.NewSchemaType("boolean")
+				child.Description = description
+			}
+			s.Properties.Set(key, child)
+		}
+		s = child
+	}
+}
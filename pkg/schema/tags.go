@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samber/lo"
+	"go.yaml.in/yaml/v4"
+)
+
+// ReadTagSchemas reads the tag schemas file, which maps yaml tags to the schema
+// used for values with that tag:
+//
+//	"!secret":
+//	  type: string
+//	  writeOnly: true
+func ReadTagSchemas(path string) (map[string]*yaml.Node, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(content, root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("tag schemas must be a mapping: %s", path)
+	}
+
+	tagSchemas := map[string]*yaml.Node{}
+	for _, pair := range lo.Chunk(root.Content[0].Content, 2) {
+		if pair[1].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("schema for tag %s must be a mapping: %s", pair[0].Value, path)
+		}
+		tagSchemas[pair[0].Value] = pair[1]
+	}
+
+	return tagSchemas, nil
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+const NullType = "null"
+
+// SchemaType is the jsonschema type keyword, which is either a single type
+// or a list of types. A single type is encoded as a string.
+type SchemaType []string
+
+func NewSchemaType(types ...string) SchemaType {
+	return SchemaType(types)
+}
+
+// Is reports whether the type includes the named type.
+func (t SchemaType) Is(name string) bool {
+	return slices.Contains(t, name)
+}
+
+// WithNull returns the type including null.
+func (t SchemaType) WithNull() SchemaType {
+	if len(t) == 0 || t.Is(NullType) {
+		return t
+	}
+	return append(slices.Clone(t), NullType)
+}
+
+func (t SchemaType) String() string {
+	return strings.Join(t, ", ")
+}
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = SchemaType(multiple)
+	return nil
+}
+
+func (t SchemaType) MarshalYAML() (any, error) {
+	if len(t) == 1 {
+		return t[0], nil
+	}
+	return []string(t), nil
+}
+
+// UnmarshalYAML accepts a single type or a list of types. An unquoted null is
+// read as the null type rather than the absence of a type.
+func (t *SchemaType) UnmarshalYAML(node *yaml.Node) error {
+	items := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		items = node.Content
+	}
+
+	types := SchemaType{}
+	for _, item := range items {
+		if item.Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: type must be a string or list of strings", item.Line)
+		}
+		if item.Tag == "!!null" {
+			types = append(types, NullType)
+			continue
+		}
+		types = append(types, item.Value)
+	}
+
+	*t = types
+	return nil
+}
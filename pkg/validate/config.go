@@ -0,0 +1,25 @@
+package validate
+
+import (
+	"helmvalues/pkg/schema"
+	"helmvalues/pkg/schema/comments"
+
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+)
+
+type Config struct {
+	ValuesFiles          []string
+	Merge                bool
+	SetValues            []string
+	SetStringValues      []string
+	SetJSONValues        []string
+	Schema               schema.Source
+	TagSchemas           map[string]*yaml.Node
+	ComposeSubcharts     bool
+	AdditionalProperties schema.AdditionalPropertiesPolicy
+	CommentDialect       comments.Dialect
+	FootComments         bool
+	DetachedMarker       string
+	LogLevel             logrus.Level
+}
@@ -0,0 +1,165 @@
+package validate
+
+import (
+	"fmt"
+	"helmvalues/pkg/schema"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+)
+
+// Values is a values tree coalesced the way helm coalesces the chart's values
+// with the values files and set flags of an install. It remembers the input
+// each node was read from, so violations can be attributed to the input that
+// introduced the value.
+type Values struct {
+	logger  *logrus.Logger
+	root    *yaml.Node
+	sources map[*yaml.Node]string
+}
+
+func NewValues(logger *logrus.Logger) *Values {
+	return &Values{
+		logger:  logger,
+		root:    &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"},
+		sources: map[*yaml.Node]string{},
+	}
+}
+
+// Root returns the mapping node of the coalesced values.
+func (v *Values) Root() *yaml.Node {
+	return v.root
+}
+
+// Source returns the input the node was read from.
+func (v *Values) Source(node *yaml.Node) string {
+	return v.sources[node]
+}
+
+// MergeFile merges the values file into the values, like helm merges the
+// files passed with -f. Maps are merged and any other value replaces the
+// value before it, including null.
+func (v *Values) MergeFile(path string, document *yaml.Node) error {
+	content := documentContent(document)
+	if content == nil {
+		return nil
+	}
+
+	node, err := v.copyFrom(path, content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: values file must be a map", path)
+	}
+
+	v.claimRoot(path, node)
+	v.mergeMaps(v.root, node)
+	return nil
+}
+
+// Coalesce places the chart's default values under the values. A null value
+// removes the key from the defaults, and a value which disagrees with the
+// default on being a map replaces it.
+func (v *Values) Coalesce(path string, defaults *yaml.Node) error {
+	content := documentContent(defaults)
+	if content == nil {
+		return nil
+	}
+
+	node, err := v.copyFrom(path, content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: values file must be a map", path)
+	}
+
+	v.claimRoot(path, node)
+	v.coalesceMaps(v.root, node, []string{})
+	return nil
+}
+
+func (v *Values) mergeMaps(dst *yaml.Node, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		idx := mappingIndex(dst, key.Value)
+		if idx == -1 {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+
+		existing := dst.Content[idx+1]
+		if existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			v.mergeMaps(existing, value)
+			continue
+		}
+		dst.Content[idx], dst.Content[idx+1] = key, value
+	}
+}
+
+func (v *Values) coalesceMaps(dst *yaml.Node, src *yaml.Node, path []string) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		keyPath := keyPath(append(slices.Clone(path), key.Value))
+
+		idx := mappingIndex(dst, key.Value)
+		if idx == -1 {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+
+		existing := dst.Content[idx+1]
+		switch {
+		case nodeType(existing) == "null":
+			v.logger.Debugf("validate: %s: %s: null removes the default value", v.sources[existing], keyPath)
+			dst.Content = slices.Delete(dst.Content, idx, idx+2)
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			v.coalesceMaps(existing, value, append(slices.Clone(path), key.Value))
+		case value.Kind == yaml.MappingNode:
+			v.logger.Debugf("validate: %s: %s: non-map value replaces the default map", v.sources[existing], keyPath)
+		case existing.Kind == yaml.MappingNode && nodeType(value) != "null":
+			v.logger.Debugf("validate: %s: %s: map replaces the default non-map value", v.sources[existing], keyPath)
+		}
+	}
+}
+
+// copyFrom copies the node, so merging never changes the input documents, and
+// records the source of each node in the copy.
+func (v *Values) copyFrom(source string, node *yaml.Node) (*yaml.Node, error) {
+	c, err := schema.ResolvedCopy(node)
+	if err != nil {
+		return nil, err
+	}
+	v.setSource(source, c)
+	return c, nil
+}
+
+func (v *Values) setSource(source string, node *yaml.Node) {
+	v.sources[node] = source
+	for _, child := range node.Content {
+		v.setSource(source, child)
+	}
+}
+
+// claimRoot attributes the root of the values to the first input.
+func (v *Values) claimRoot(source string, node *yaml.Node) {
+	if _, ok := v.sources[v.root]; ok {
+		return
+	}
+	v.sources[v.root] = source
+	v.root.Line, v.root.Column = node.Line, node.Column
+}
+
+// mappingIndex returns the index of the key node in the mapping's content, or
+// -1 if the mapping doesn't have the key.
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,127 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+func encodeValues(t *testing.T, values *Values) string {
+	t.Helper()
+	content, err := yaml.Marshal(values.Root())
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestMergeValues(t *testing.T) {
+	t.Run("values files merge maps and replace other values", func(tt *testing.T) {
+		values := NewValues(logrus.New())
+		require.NoError(tt, values.MergeFile("a.yaml", parseDocument(tt, "image:\n  repository: nginx\n  tag: \"1.0\"\nports: [80, 443]\n")))
+		require.NoError(tt, values.MergeFile("b.yaml", parseDocument(tt, "image:\n  tag: \"2.0\"\nports: [8080]\n")))
+
+		assert.Equal(tt, "image:\n    repository: nginx\n    tag: \"2.0\"\nports: [8080]\n", encodeValues(tt, values))
+	})
+
+	t.Run("null removes defaults", func(tt *testing.T) {
+		values := NewValues(logrus.New())
+		require.NoError(tt, values.MergeFile("a.yaml", parseDocument(tt, "resources: null\nimage:\n  tag: null\nextra: null\n")))
+		require.NoError(tt, values.Coalesce("values.yaml", parseDocument(tt, "resources:\n  limits: {}\nimage:\n  repository: nginx\n  tag: latest\n")))
+
+		assert.Equal(tt, "image:\n    repository: nginx\nextra: null\n", encodeValues(tt, values))
+	})
+
+	t.Run("values replace defaults of a different kind", func(tt *testing.T) {
+		values := NewValues(logrus.New())
+		require.NoError(tt, values.MergeFile("a.yaml", parseDocument(tt, "image: nginx\nports:\n  http: 80\n")))
+		require.NoError(tt, values.Coalesce("values.yaml", parseDocument(tt, "image:\n  repository: nginx\nports: [80]\n")))
+
+		assert.Equal(tt, "image: nginx\nports:\n    http: 80\n", encodeValues(tt, values))
+	})
+}
+
+func TestMergeSet(t *testing.T) {
+	testCases := []struct {
+		name     string
+		flag     SetFlag
+		value    string
+		expected string
+	}{
+		{
+			name:     "types are inferred",
+			flag:     SetFlagValue,
+			value:    "a=true,b=10,c=010,d=1.5,e=null,f=",
+			expected: "a: true\nb: 10\nc: \"010\"\nd: \"1.5\"\ne: null\nf: \"\"\n",
+		},
+		{
+			name:     "string values",
+			flag:     SetFlagString,
+			value:    "a=true,b=10",
+			expected: "a: \"true\"\nb: \"10\"\n",
+		},
+		{
+			name:     "escaped dots and commas",
+			flag:     SetFlagValue,
+			value:    `nodeSelector.kubernetes\.io/os=linux,name=a\,b`,
+			expected: "nodeSelector:\n    kubernetes.io/os: linux\nname: a,b\n",
+		},
+		{
+			name:     "list indices",
+			flag:     SetFlagValue,
+			value:    "hosts[1].name=foo,ports[0]=80",
+			expected: "hosts:\n    - null\n    - name: foo\nports:\n    - 80\n",
+		},
+		{
+			name:     "lists",
+			flag:     SetFlagValue,
+			value:    "args={a,b},c=d",
+			expected: "args:\n    - a\n    - b\nc: d\n",
+		},
+		{
+			name:     "json values",
+			flag:     SetFlagJSON,
+			value:    `image={"tag": "1.0", "pull": [1, 2]},replicas=3`,
+			expected: "image: {\"tag\": \"1.0\", \"pull\": [1, 2]}\nreplicas: 3\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(tt *testing.T) {
+			values := NewValues(logrus.New())
+			require.NoError(tt, values.MergeSet(tc.flag, tc.value))
+			assert.Equal(tt, tc.expected, encodeValues(tt, values))
+		})
+	}
+
+	t.Run("invalid key paths", func(tt *testing.T) {
+		for _, value := range []string{"a", "a..b=1", "[0]=1", "a[x]=1", "a[70000]=1", "a[0=1"} {
+			values := NewValues(logrus.New())
+			assert.Error(tt, values.MergeSet(SetFlagValue, value), value)
+		}
+	})
+}
+
+func TestValidateMergedValues(t *testing.T) {
+	values := NewValues(logrus.New())
+	require.NoError(t, values.MergeFile("prod.yaml", parseDocument(t, "replicas: 20\nimage:\n  repository: nginx\nname: null\n")))
+	require.NoError(t, values.MergeSet(SetFlagValue, "image.repository=NGINX,ports[0]=http"))
+	require.NoError(t, values.Coalesce("values.yaml", parseDocument(t, "name: chart\nlogLevel: trace\n")))
+
+	validator := newTestValidator(t, "")
+	violations, err := validator.ValidateValues(values)
+	require.NoError(t, err)
+
+	lines := []string{}
+	for _, violation := range violations {
+		lines = append(lines, violation.String())
+	}
+	assert.Equal(t, []string{
+		"prod.yaml:1:1: name: is required",
+		"prod.yaml:1:1: replicas: must be at most 10",
+		"--set image.repository=NGINX: image.repository: must match pattern ^[a-z]+$",
+		"--set ports[0]=http: ports[0]: expected integer, got string",
+		"values.yaml:2:1: logLevel: must be one of: \"debug\", \"info\"",
+	}, lines)
+}
@@ -0,0 +1,303 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// SetFlag is a helm flag which sets values by key path.
+type SetFlag string
+
+const (
+	// SetFlagJSON sets json values, as --set-json does
+	SetFlagJSON SetFlag = "--set-json"
+	// SetFlagValue sets values whose type is inferred, as --set does
+	SetFlagValue SetFlag = "--set"
+	// SetFlagString sets string values, as --set-string does
+	SetFlagString SetFlag = "--set-string"
+)
+
+// maxSetIndex is the largest list index helm allows in a set flag.
+const maxSetIndex = 65536
+
+// setPathElement is a map key or list index of a set flag's key path.
+type setPathElement struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// MergeSet sets the comma separated key path assignments of the flag value
+// into the values, like helm does for the set flags. Key paths use dots
+// between map keys, which can be escaped with a backslash, and brackets for
+// list indices:
+//
+//	image.tag=1.0,nodeSelector.kubernetes\.io/os=linux,hosts[0].name=foo
+func (v *Values) MergeSet(flag SetFlag, value string) error {
+	rest := value
+	for rest != "" {
+		keyStr, afterKey, found := cutUnescaped(rest, '=')
+		if !found {
+			return fmt.Errorf("%s %s: key %q has no value", flag, value, keyStr)
+		}
+		path, err := parseSetPath(keyStr)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", flag, value, err)
+		}
+
+		var node *yaml.Node
+		var valueStr string
+		if flag == SetFlagJSON {
+			node, valueStr, rest, err = parseSetJSON(afterKey)
+		} else {
+			node, valueStr, rest, err = parseSetValue(afterKey, flag == SetFlagString)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: %s: %w", flag, value, keyStr, err)
+		}
+
+		source := fmt.Sprintf("%s %s=%s", flag, keyStr, valueStr)
+		v.claimRoot(source, &yaml.Node{})
+		v.setSource(source, node)
+		v.root = v.setPath(source, v.root, path, node)
+	}
+	return nil
+}
+
+// setPath sets the value at the key path below the node, returning the node
+// which replaces it. Nodes along the path which aren't of the kind the path
+// needs are replaced.
+func (v *Values) setPath(source string, node *yaml.Node, path []setPathElement, value *yaml.Node) *yaml.Node {
+	if len(path) == 0 {
+		return value
+	}
+	element := path[0]
+
+	if element.isIndex {
+		if node == nil || node.Kind != yaml.SequenceNode {
+			node = v.newNode(source, yaml.SequenceNode, "!!seq")
+		}
+		for len(node.Content) <= element.index {
+			null := v.newNode(source, yaml.ScalarNode, "!!null")
+			null.Value = "null"
+			node.Content = append(node.Content, null)
+		}
+		node.Content[element.index] = v.setPath(source, node.Content[element.index], path[1:], value)
+		return node
+	}
+
+	if node == nil || node.Kind != yaml.MappingNode {
+		node = v.newNode(source, yaml.MappingNode, "!!map")
+	}
+	idx := mappingIndex(node, element.key)
+	if idx == -1 {
+		key := v.newNode(source, yaml.ScalarNode, "!!str")
+		key.Value = element.key
+		node.Content = append(node.Content, key, v.setPath(source, nil, path[1:], value))
+		return node
+	}
+
+	child := v.setPath(source, node.Content[idx+1], path[1:], value)
+	if child != node.Content[idx+1] {
+		// the key is attributed to the input which set its value
+		key := v.newNode(source, yaml.ScalarNode, "!!str")
+		key.Value = element.key
+		node.Content[idx] = key
+	}
+	node.Content[idx+1] = child
+	return node
+}
+
+func (v *Values) newNode(source string, kind yaml.Kind, tag string) *yaml.Node {
+	node := &yaml.Node{Kind: kind, Tag: tag}
+	v.sources[node] = source
+	return node
+}
+
+// parseSetPath parses the key path of a set flag assignment.
+func parseSetPath(keyStr string) ([]setPathElement, error) {
+	path := []setPathElement{}
+	key := strings.Builder{}
+	// a key is pending until a dot or an index ends it
+	pending := true
+
+	runes := []rune(keyStr)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			key.WriteRune(runes[i])
+			pending = true
+		case r == '.':
+			if pending {
+				if key.Len() == 0 {
+					return nil, fmt.Errorf("key %q has an empty key", keyStr)
+				}
+				path = append(path, setPathElement{key: key.String()})
+			}
+			key.Reset()
+			pending = true
+		case r == '[':
+			if pending && key.Len() > 0 {
+				path = append(path, setPathElement{key: key.String()})
+			} else if len(path) == 0 {
+				return nil, fmt.Errorf("key %q starts with a list index", keyStr)
+			}
+			key.Reset()
+
+			end := strings.IndexRune(string(runes[i:]), ']')
+			if end == -1 {
+				return nil, fmt.Errorf("key %q has an unclosed list index", keyStr)
+			}
+			indexStr := string(runes[i:])[1:end]
+			index, err := strconv.Atoi(indexStr)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("key %q has an invalid list index %q", keyStr, indexStr)
+			}
+			if index > maxSetIndex {
+				return nil, fmt.Errorf("key %q has a list index greater than %d", keyStr, maxSetIndex)
+			}
+			path = append(path, setPathElement{index: index, isIndex: true})
+			i += len([]rune(string(runes[i:])[:end]))
+			pending = false
+		default:
+			key.WriteRune(r)
+			pending = true
+		}
+	}
+
+	if pending {
+		if key.Len() == 0 {
+			return nil, fmt.Errorf("key %q has an empty key", keyStr)
+		}
+		path = append(path, setPathElement{key: key.String()})
+	}
+	return path, nil
+}
+
+// parseSetValue parses the value of a --set or --set-string assignment,
+// returning the value node, the value as written and the assignments after
+// it. A value in braces is a list.
+func parseSetValue(s string, asString bool) (*yaml.Node, string, string, error) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexRune(s, '}')
+		if end == -1 {
+			return nil, "", "", errors.New("list value is not closed")
+		}
+		list := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if items := s[1:end]; items != "" {
+			for {
+				item, rest, found := cutUnescaped(items, ',')
+				list.Content = append(list.Content, typedNode(unescape(item), asString))
+				if !found {
+					break
+				}
+				items = rest
+			}
+		}
+
+		rest := s[end+1:]
+		if rest != "" && !strings.HasPrefix(rest, ",") {
+			return nil, "", "", errors.New("list value must be followed by a comma")
+		}
+		return list, s[:end+1], strings.TrimPrefix(rest, ","), nil
+	}
+
+	value, rest, _ := cutUnescaped(s, ',')
+	return typedNode(unescape(value), asString), value, rest, nil
+}
+
+// parseSetJSON parses the json value of a --set-json assignment, returning
+// the value node, the value as written and the assignments after it.
+func parseSetJSON(s string) (*yaml.Node, string, string, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, "", "", err
+	}
+	valueStr := strings.TrimSpace(s[:decoder.InputOffset()])
+
+	rest := strings.TrimLeft(s[decoder.InputOffset():], " ")
+	if rest != "" && !strings.HasPrefix(rest, ",") {
+		return nil, "", "", errors.New("json value must be followed by a comma")
+	}
+
+	// json is yaml, so the value is read into nodes like the values files
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal(bytes.TrimSpace(raw), document); err != nil {
+		return nil, "", "", err
+	}
+	node := documentContent(document)
+	clearPositions(node)
+	return node, valueStr, strings.TrimPrefix(rest, ","), nil
+}
+
+// typedNode returns the scalar node of a set flag value. Like helm, --set
+// reads true, false, null and integers as their types, while --set-string
+// reads every value as a string.
+func typedNode(value string, asString bool) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if asString {
+		return node
+	}
+
+	switch {
+	case strings.EqualFold(value, "true"), strings.EqualFold(value, "false"):
+		node.Tag, node.Value = "!!bool", strings.ToLower(value)
+	case strings.EqualFold(value, "null"):
+		node.Tag, node.Value = "!!null", "null"
+	case value == "0":
+		node.Tag = "!!int"
+	case value != "" && value[0] != '0':
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			node.Tag = "!!int"
+		}
+	}
+	return node
+}
+
+// cutUnescaped slices s around the first separator not escaped by a
+// backslash. The text before the separator keeps its escapes.
+func cutUnescaped(s string, separator rune) (string, string, bool) {
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == separator:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+func unescape(s string) string {
+	b := strings.Builder{}
+	escaped := false
+	for _, r := range s {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// clearPositions zeroes the positions of the nodes, which have no line in a
+// values file.
+func clearPositions(node *yaml.Node) {
+	node.Line, node.Column = 0, 0
+	for _, child := range node.Content {
+		clearPositions(child)
+	}
+}
@@ -0,0 +1,153 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"helmvalues/internal/charts"
+	"helmvalues/pkg"
+	"helmvalues/pkg/schema"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+)
+
+// ErrInvalidValues is returned by ValidateValues when a values file doesn't
+// satisfy the schema of its chart.
+var ErrInvalidValues = errors.New("values files are invalid")
+
+// ValidateValues validates the values files against the schema of each chart,
+// printing the violations found.
+func ValidateValues(logger *logrus.Logger, cfg *Config, chartDirs []string) error {
+	hasSetValues := len(cfg.SetValues)+len(cfg.SetStringValues)+len(cfg.SetJSONValues) > 0
+	if hasSetValues && !cfg.Merge {
+		return errors.New("set flags can only be validated with --merge")
+	}
+	if len(cfg.ValuesFiles) == 0 && !hasSetValues {
+		return errors.New("no values files to validate")
+	}
+
+	chartsFound, err := charts.Search(logger, chartDirs)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, chart := range chartsFound {
+		logger.Infof("validate: %s: starting validation", chart.Details.Name)
+
+		jsonschema, err := chartSchema(logger, cfg, chart)
+		if err != nil {
+			return err
+		}
+
+		defaults, err := readDocument(chart.ValuesFilePath())
+		if err != nil {
+			return err
+		}
+
+		validator := NewValidator(logger, jsonschema, defaults)
+		if cfg.Merge {
+			violations, err := validateMerged(logger, cfg, validator, chart.ValuesFilePath(), defaults)
+			if err != nil {
+				return err
+			}
+			for _, violation := range violations {
+				fmt.Println(violation)
+			}
+			total += len(violations)
+
+			logger.Infof("validate: %s: finished", chart.Details.Name)
+			continue
+		}
+
+		for _, valuesFile := range cfg.ValuesFiles {
+			logger.Debugf("validate: %s: validating %s", chart.Details.Name, valuesFile)
+
+			document, err := readDocument(valuesFile)
+			if err != nil {
+				return err
+			}
+
+			violations, err := validator.Validate(valuesFile, document)
+			if err != nil {
+				return fmt.Errorf("%s: %w", valuesFile, err)
+			}
+			for _, violation := range violations {
+				fmt.Println(violation)
+			}
+			total += len(violations)
+		}
+
+		logger.Infof("validate: %s: finished", chart.Details.Name)
+	}
+
+	if total > 0 {
+		return fmt.Errorf("%w: %d violations", ErrInvalidValues, total)
+	}
+	return nil
+}
+
+// validateMerged coalesces the chart's values with the values files and set
+// flags, in the order helm applies them, and validates the result.
+func validateMerged(logger *logrus.Logger, cfg *Config, validator *Validator, defaultsPath string, defaults *yaml.Node) ([]Violation, error) {
+	values := NewValues(logger)
+	for _, valuesFile := range cfg.ValuesFiles {
+		document, err := readDocument(valuesFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := values.MergeFile(valuesFile, document); err != nil {
+			return nil, err
+		}
+	}
+
+	sets := []struct {
+		flag   SetFlag
+		values []string
+	}{
+		{SetFlagJSON, cfg.SetJSONValues},
+		{SetFlagValue, cfg.SetValues},
+		{SetFlagString, cfg.SetStringValues},
+	}
+	for _, set := range sets {
+		for _, value := range set.values {
+			if err := values.MergeSet(set.flag, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := values.Coalesce(defaultsPath, defaults); err != nil {
+		return nil, err
+	}
+	return validator.ValidateValues(values)
+}
+
+// chartSchema returns the schema to validate the chart's values against.
+func chartSchema(logger *logrus.Logger, cfg *Config, chart *charts.Chart) (*pkg.JsonSchema, error) {
+	schemaCfg := &schema.Config{
+		DryRun:               true,
+		TagSchemas:           cfg.TagSchemas,
+		ComposeSubcharts:     cfg.ComposeSubcharts,
+		AdditionalProperties: cfg.AdditionalProperties,
+		CommentDialect:       cfg.CommentDialect,
+		FootComments:         cfg.FootComments,
+		DetachedMarker:       cfg.DetachedMarker,
+		LogLevel:             cfg.LogLevel,
+	}
+	return schema.LoadSchema(logger, cfg.Schema, schemaCfg, chart)
+}
+
+func readDocument(path string) (*yaml.Node, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal(content, document); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return document, nil
+}
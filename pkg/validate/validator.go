@@ -0,0 +1,569 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"helmvalues/pkg"
+	"helmvalues/pkg/schema"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+)
+
+// Violation is a value that doesn't satisfy the schema. File is the values
+// file, or the set flag, the value was read from.
+type Violation struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	// values from set flags have no position
+	if v.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", v.File, path, v.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", v.File, v.Line, v.Column, path, v.Message)
+}
+
+// Validator checks values files against a schema. The draft-07 and 2020-12
+// validation keywords are supported, except for format and the unevaluated
+// keywords, which are ignored. Only local references are followed, and
+// patterns Go's regexp package can't compile are skipped.
+type Validator struct {
+	logger   *logrus.Logger
+	root     *pkg.JsonSchema
+	defaults *yaml.Node
+	patterns map[string]*regexp.Regexp
+}
+
+// NewValidator returns a validator for the root schema. Values files are
+// validated as overrides of the defaults document, so required keys may be
+// set by either.
+func NewValidator(logger *logrus.Logger, root *pkg.JsonSchema, defaults *yaml.Node) *Validator {
+	return &Validator{
+		logger:   logger,
+		root:     root,
+		defaults: documentContent(defaults),
+		patterns: map[string]*regexp.Regexp{},
+	}
+}
+
+// Validate returns the violations of the values document, read from the file.
+func (v *Validator) Validate(file string, document *yaml.Node) ([]Violation, error) {
+	value := documentContent(document)
+	if value == nil {
+		return nil, nil
+	}
+
+	c := &validation{validator: v, file: file}
+	c.validate(v.root, value, value, []string{})
+	return c.violations, c.err
+}
+
+// ValidateValues returns the violations of the coalesced values, attributed
+// to the inputs the values were read from. The values already hold the chart's
+// defaults, so they aren't consulted for required keys.
+func (v *Validator) ValidateValues(values *Values) ([]Violation, error) {
+	c := &validation{validator: v, file: values.Source(values.Root()), values: values}
+	c.validate(v.root, values.Root(), values.Root(), []string{})
+	return c.violations, c.err
+}
+
+// validation holds the state of validating a single values file, or the
+// coalesced values when values is set.
+type validation struct {
+	validator  *Validator
+	file       string
+	values     *Values
+	violations []Violation
+	err        error
+}
+
+// validate checks the value against the schema. The position node is the key
+// of the value when it has one, so violations point at the key's line.
+func (c *validation) validate(s *pkg.JsonSchema, value *yaml.Node, position *yaml.Node, path []string) {
+	if s == nil || c.err != nil {
+		return
+	}
+	value = schema.ResolveAlias(value)
+
+	if s.Ref != "" {
+		c.validateRef(s.Ref, value, position, path)
+	}
+
+	valueType := nodeType(value)
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return typeMatches(t, valueType, value) }) {
+		c.report(position, path, "expected %s, got %s", s.Type, valueType)
+		return
+	}
+
+	if s.Const != nil && !equalValues(s.Const, decodeValue(value)) {
+		c.report(position, path, "must be %s", jsonString(s.Const))
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equalValues(e, decodeValue(value)) }) {
+		c.report(position, path, "must be one of: %s", strings.Join(jsonStrings(s.Enum), ", "))
+	}
+
+	switch valueType {
+	case "object":
+		c.validateObject(s, value, position, path)
+	case "array":
+		c.validateArray(s, value, position, path)
+	case "string":
+		c.validateString(s, value, position, path)
+	case "integer", "number":
+		c.validateNumber(s, value, position, path)
+	}
+
+	c.validateCombinators(s, value, position, path)
+}
+
+func (c *validation) validateRef(ref string, value *yaml.Node, position *yaml.Node, path []string) {
+	target, ok := c.validator.root.ResolveLocalRef(ref)
+	if ref == "#" {
+		target, ok = c.validator.root, true
+	}
+	if !ok {
+		c.validator.logger.Debugf("validate: %s: %s: skipping unresolved $ref %s", c.file, keyPath(path), ref)
+		return
+	}
+	c.validate(target, value, position, path)
+}
+
+func (c *validation) validateObject(s *pkg.JsonSchema, value *yaml.Node, position *yaml.Node, path []string) {
+	pairs, err := schema.MappingPairs(value)
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	keys := []string{}
+	for _, pair := range pairs {
+		if !c.removesDefault(pair[1]) {
+			keys = append(keys, pair[0].Value)
+		}
+	}
+
+	for _, required := range s.Required {
+		if !slices.Contains(keys, required) && !c.hasDefault(append(slices.Clone(path), required)) {
+			c.report(position, append(slices.Clone(path), required), "is required")
+		}
+	}
+	for key, dependents := range s.DependentRequired {
+		c.validateDependentRequired(key, dependents, keys, position, path)
+	}
+	if s.MinProperties != nil && int64(len(keys)) < *s.MinProperties {
+		c.report(position, path, "must have at least %d keys", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && int64(len(keys)) > *s.MaxProperties {
+		c.report(position, path, "must have at most %d keys", *s.MaxProperties)
+	}
+
+	for _, pair := range pairs {
+		key, child := pair[0], pair[1]
+		if c.removesDefault(child) {
+			continue
+		}
+		childPath := append(slices.Clone(path), key.Value)
+
+		if s.PropertyNames != nil {
+			c.validate(s.PropertyNames, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.Value}, key, childPath)
+		}
+
+		matched := false
+		if s.Properties != nil {
+			if property, ok := s.Properties.Get(key.Value); ok {
+				matched = true
+				c.validate(property, child, key, childPath)
+			}
+		}
+		if s.PatternProperties != nil {
+			for pattern, property := range s.PatternProperties.AllFromFront() {
+				match, supported := c.matches(pattern, key.Value)
+				if !supported {
+					// the key may be declared by the pattern, so it isn't reported
+					matched = true
+				} else if match {
+					matched = true
+					c.validate(property, child, key, childPath)
+				}
+			}
+		}
+		if matched || s.AdditionalProperties == nil {
+			continue
+		}
+
+		if allowed, ok := s.AdditionalProperties.(bool); ok && !allowed {
+			c.report(key, childPath, "key is not declared in the schema")
+			continue
+		}
+		c.validate(pkg.KeywordSchema(s.AdditionalProperties), child, key, childPath)
+	}
+
+	for key, dependency := range s.Dependencies {
+		if !slices.Contains(keys, key) {
+			continue
+		}
+		if dependents, ok := dependency.([]any); ok {
+			c.validateDependentRequired(key, jsonStrings(dependents), keys, position, path)
+			continue
+		}
+		c.validate(pkg.KeywordSchema(dependency), value, position, path)
+	}
+	for key, dependency := range s.DependentSchemas {
+		if slices.Contains(keys, key) {
+			c.validate(dependency, value, position, path)
+		}
+	}
+}
+
+func (c *validation) validateDependentRequired(key string, dependents []string, keys []string, position *yaml.Node, path []string) {
+	if !slices.Contains(keys, key) {
+		return
+	}
+	for _, dependent := range dependents {
+		dependent = strings.Trim(dependent, `"`)
+		if !slices.Contains(keys, dependent) {
+			c.report(position, append(slices.Clone(path), dependent), "is required when %s is set", key)
+		}
+	}
+}
+
+func (c *validation) validateArray(s *pkg.JsonSchema, value *yaml.Node, position *yaml.Node, path []string) {
+	items := value.Content
+	if s.MinItems != nil && int64(len(items)) < *s.MinItems {
+		c.report(position, path, "must have at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && int64(len(items)) > *s.MaxItems {
+		c.report(position, path, "must have at most %d items", *s.MaxItems)
+	}
+
+	if s.UniqueItems {
+		seen := map[string]int{}
+		for i, item := range items {
+			encoded := jsonString(decodeValue(item))
+			if first, ok := seen[encoded]; ok {
+				c.report(item, itemPath(path, i), "duplicates item %d", first)
+				continue
+			}
+			seen[encoded] = i
+		}
+	}
+
+	// prefixItems (2020-12) or an items array (draft-07) describe the leading
+	// items, and items or additionalItems describe the rest
+	prefix := s.PrefixItems
+	rest := pkg.KeywordSchema(s.Items)
+	if tuple, ok := s.Items.([]any); ok {
+		prefix = []*pkg.JsonSchema{}
+		for _, item := range tuple {
+			prefix = append(prefix, pkg.KeywordSchema(item))
+		}
+		rest = pkg.KeywordSchema(s.AdditionalItems)
+	} else if len(s.PrefixItems) == 0 {
+		prefix = nil
+	}
+
+	for i, item := range items {
+		if i < len(prefix) {
+			c.validate(prefix[i], item, item, itemPath(path, i))
+			continue
+		}
+		if allowed, ok := s.Items.(bool); ok && !allowed && len(s.PrefixItems) > 0 {
+			c.report(item, itemPath(path, i), "item is not declared in the schema")
+			continue
+		}
+		c.validate(rest, item, item, itemPath(path, i))
+	}
+
+	if s.Contains != nil {
+		count := int64(0)
+		for i, item := range items {
+			if c.satisfies(s.Contains, item, itemPath(path, i)) {
+				count++
+			}
+		}
+
+		minContains := int64(1)
+		if s.MinContains != nil {
+			minContains = *s.MinContains
+		}
+		if count < minContains {
+			c.report(position, path, "must contain at least %d matching items", minContains)
+		}
+		if s.MaxContains != nil && count > *s.MaxContains {
+			c.report(position, path, "must contain at most %d matching items", *s.MaxContains)
+		}
+	}
+}
+
+func (c *validation) validateString(s *pkg.JsonSchema, value *yaml.Node, position *yaml.Node, path []string) {
+	length := int64(utf8.RuneCountInString(value.Value))
+	if s.MinLength != nil && length < *s.MinLength {
+		c.report(position, path, "must be at least %d characters", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		c.report(position, path, "must be at most %d characters", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		if match, supported := c.matches(s.Pattern, value.Value); supported && !match {
+			c.report(position, path, "must match pattern %s", s.Pattern)
+		}
+	}
+}
+
+func (c *validation) validateNumber(s *pkg.JsonSchema, value *yaml.Node, position *yaml.Node, path []string) {
+	var n float64
+	if err := value.Decode(&n); err != nil {
+		c.err = fmt.Errorf("line %d: %w", value.Line, err)
+		return
+	}
+
+	if limit, ok := numberValue(s.Minimum); ok {
+		if exclusive, _ := s.ExclusiveMinimum.Bool(); exclusive && n <= limit {
+			c.report(position, path, "must be greater than %s", s.Minimum)
+		} else if n < limit {
+			c.report(position, path, "must be at least %s", s.Minimum)
+		}
+	}
+	if limit, ok := s.ExclusiveMinimum.Number(); ok {
+		if f, ok := numberValue(limit); ok && n <= f {
+			c.report(position, path, "must be greater than %s", limit)
+		}
+	}
+	if limit, ok := numberValue(s.Maximum); ok {
+		if exclusive, _ := s.ExclusiveMaximum.Bool(); exclusive && n >= limit {
+			c.report(position, path, "must be less than %s", s.Maximum)
+		} else if n > limit {
+			c.report(position, path, "must be at most %s", s.Maximum)
+		}
+	}
+	if limit, ok := s.ExclusiveMaximum.Number(); ok {
+		if f, ok := numberValue(limit); ok && n >= f {
+			c.report(position, path, "must be less than %s", limit)
+		}
+	}
+	if multipleOf, ok := numberValue(s.MultipleOf); ok && multipleOf > 0 {
+		quotient := n / multipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			c.report(position, path, "must be a multiple of %s", s.MultipleOf)
+		}
+	}
+}
+
+func (c *validation) validateCombinators(s *pkg.JsonSchema, value *yaml.Node, position *yaml.Node, path []string) {
+	for _, sub := range s.AllOf {
+		c.validate(sub, value, position, path)
+	}
+
+	if len(s.AnyOf) > 0 && !slices.ContainsFunc(s.AnyOf, func(sub *pkg.JsonSchema) bool { return c.satisfies(sub, value, path) }) {
+		c.report(position, path, "must match at least one anyOf schema")
+	}
+
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, sub := range s.OneOf {
+			if c.satisfies(sub, value, path) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			c.report(position, path, "must match exactly one oneOf schema, matched %d", matches)
+		}
+	}
+
+	if s.Not != nil && c.satisfies(s.Not, value, path) {
+		c.report(position, path, "must not match the not schema")
+	}
+
+	if s.If != nil {
+		if c.satisfies(s.If, value, path) {
+			c.validate(s.Then, value, position, path)
+		} else {
+			c.validate(s.Else, value, position, path)
+		}
+	}
+}
+
+// satisfies reports whether the value is valid against the schema, without
+// reporting its violations.
+func (c *validation) satisfies(s *pkg.JsonSchema, value *yaml.Node, path []string) bool {
+	sub := &validation{validator: c.validator, file: c.file, values: c.values}
+	sub.validate(s, value, value, path)
+	if sub.err != nil {
+		c.err = sub.err
+	}
+	return len(sub.violations) == 0
+}
+
+// removesDefault reports whether the value is a null in a values file, which
+// removes the key from the chart's values like helm does. Nulls left in
+// coalesced values are validated.
+func (c *validation) removesDefault(value *yaml.Node) bool {
+	return c.values == nil && nodeType(schema.ResolveAlias(value)) == "null"
+}
+
+// hasDefault reports whether the defaults document sets a non-null value at
+// the key path.
+func (c *validation) hasDefault(path []string) bool {
+	if c.values != nil {
+		return false
+	}
+
+	node := c.validator.defaults
+	for _, key := range path {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return false
+		}
+		pairs, err := schema.MappingPairs(node)
+		if err != nil {
+			return false
+		}
+
+		var next *yaml.Node
+		for _, pair := range pairs {
+			if pair[0].Value == key {
+				next = schema.ResolveAlias(pair[1])
+			}
+		}
+		node = next
+	}
+	return node != nil && nodeType(node) != "null"
+}
+
+// matches reports whether the value matches the pattern, and whether the
+// pattern is supported. Patterns are ECMA-262 regular expressions, and those
+// using syntax Go doesn't support (eg: lookarounds) are skipped with a warning.
+func (c *validation) matches(pattern string, value string) (bool, bool) {
+	re, ok := c.validator.patterns[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			c.validator.logger.Warnf("validate: skipping unsupported pattern %s: %v", pattern, err)
+		}
+		c.validator.patterns[pattern] = re
+	}
+	if re == nil {
+		return false, false
+	}
+	return re.MatchString(value), true
+}
+
+func (c *validation) report(position *yaml.Node, path []string, format string, args ...any) {
+	file := c.file
+	if c.values != nil && c.values.Source(position) != "" {
+		file = c.values.Source(position)
+	}
+
+	c.violations = append(c.violations, Violation{
+		File:    file,
+		Line:    position.Line,
+		Column:  position.Column,
+		Path:    keyPath(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// documentContent returns the root value of the document node.
+func documentContent(document *yaml.Node) *yaml.Node {
+	if document == nil || document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil
+	}
+	return document.Content[0]
+}
+
+// nodeType returns the json schema type of the yaml value.
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// typeMatches reports whether the value of the type satisfies the schema type.
+// Integers are numbers, and numbers without a fractional part are integers.
+func typeMatches(schemaType string, valueType string, value *yaml.Node) bool {
+	switch {
+	case schemaType == valueType:
+		return true
+	case schemaType == "number" && valueType == "integer":
+		return true
+	case schemaType == "integer" && valueType == "number":
+		var n float64
+		return value.Decode(&n) == nil && n == math.Trunc(n) && !math.IsInf(n, 0)
+	default:
+		return false
+	}
+}
+
+func numberValue(n pkg.Number) (float64, bool) {
+	if n == "" {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func decodeValue(node *yaml.Node) any {
+	var value any
+	_ = node.Decode(&value)
+	return value
+}
+
+// equalValues compares values by their json encoding, so numbers compare
+// equal regardless of how they were decoded.
+func equalValues(a any, b any) bool {
+	return jsonString(a) == jsonString(b)
+}
+
+func jsonString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func jsonStrings(values []any) []string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = jsonString(value)
+	}
+	return strs
+}
+
+func itemPath(path []string, i int) []string {
+	return append(slices.Clone(path), fmt.Sprintf("[%d]", i))
+}
+
+// keyPath joins the keys of the path like helm's --set flag.
+func keyPath(path []string) string {
+	return strings.ReplaceAll(strings.Join(path, "."), ".[", "[")
+}
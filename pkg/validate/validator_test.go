@@ -0,0 +1,193 @@
+package validate
+
+import (
+	"helmvalues/pkg"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+const TEST_SCHEMA = `
+type: object
+required: [name]
+additionalProperties: false
+properties:
+  name:
+    type: string
+    minLength: 3
+  replicas:
+    type: integer
+    minimum: 1
+    maximum: 10
+  ratio:
+    type: number
+    exclusiveMaximum: 1
+    multipleOf: 0.25
+  logLevel:
+    enum: [debug, info]
+  image:
+    type: object
+    properties:
+      repository:
+        type: string
+        pattern: ^[a-z]+$
+  tag:
+    type: string
+    pattern: ^(?!latest$).+$
+  ports:
+    type: array
+    uniqueItems: true
+    items:
+      type: integer
+  labels:
+    type: object
+    additionalProperties:
+      type: string
+  endpoint:
+    $ref: '#/$defs/endpoint'
+  service:
+    oneOf:
+      - type: string
+      - type: object
+        required: [port]
+$defs:
+  endpoint:
+    type: object
+    required: [host]
+    properties:
+      host:
+        type: string
+`
+
+func parseDocument(t *testing.T, content string) *yaml.Node {
+	t.Helper()
+	document := &yaml.Node{}
+	require.NoError(t, yaml.Unmarshal([]byte(content), document))
+	return document
+}
+
+func newTestValidator(t *testing.T, defaults string) *Validator {
+	t.Helper()
+	s := &pkg.JsonSchema{}
+	require.NoError(t, yaml.Unmarshal([]byte(TEST_SCHEMA), s))
+	return NewValidator(logrus.New(), s, parseDocument(t, defaults))
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name       string
+		values     string
+		violations []string
+	}{
+		{
+			name:       "valid values",
+			values:     "replicas: 3\nratio: 0.5\nports: [80, 443]\nendpoint:\n  host: example.com\nservice: web\n",
+			violations: []string{},
+		},
+		{
+			name:   "types",
+			values: "replicas: three\nratio: true\n",
+			violations: []string{
+				"values.yaml:1:1: replicas: expected integer, got string",
+				"values.yaml:2:1: ratio: expected number, got boolean",
+			},
+		},
+		{
+			name:   "numeric limits",
+			values: "replicas: 11\nratio: 1\n---\n",
+			violations: []string{
+				"values.yaml:1:1: replicas: must be at most 10",
+				"values.yaml:2:1: ratio: must be less than 1",
+			},
+		},
+		{
+			name:       "multiple of",
+			values:     "ratio: 0.3\n",
+			violations: []string{"values.yaml:1:1: ratio: must be a multiple of 0.25"},
+		},
+		{
+			name:   "strings",
+			values: "name: ab\nimage:\n  repository: NGINX\n",
+			violations: []string{
+				"values.yaml:1:1: name: must be at least 3 characters",
+				"values.yaml:3:3: image.repository: must match pattern ^[a-z]+$",
+			},
+		},
+		{
+			name:       "unsupported patterns are skipped",
+			values:     "tag: latest\nreplicas: 0\n",
+			violations: []string{"values.yaml:2:1: replicas: must be at least 1"},
+		},
+		{
+			name:       "enum",
+			values:     "logLevel: trace\n",
+			violations: []string{`values.yaml:1:1: logLevel: must be one of: "debug", "info"`},
+		},
+		{
+			name:   "arrays",
+			values: "ports:\n  - 80\n  - http\n  - 80\n",
+			violations: []string{
+				"values.yaml:4:5: ports[2]: duplicates item 0",
+				"values.yaml:3:5: ports[1]: expected integer, got string",
+			},
+		},
+		{
+			name:   "additional properties",
+			values: "extra: true\nlabels:\n  team: 1\n",
+			violations: []string{
+				"values.yaml:1:1: extra: key is not declared in the schema",
+				"values.yaml:3:3: labels.team: expected string, got integer",
+			},
+		},
+		{
+			name:       "references",
+			values:     "endpoint:\n  port: 80\n",
+			violations: []string{"values.yaml:1:1: endpoint.host: is required"},
+		},
+		{
+			name:       "combinators",
+			values:     "service:\n  name: web\n",
+			violations: []string{"values.yaml:1:1: service: must match exactly one oneOf schema, matched 0"},
+		},
+		{
+			name:       "null removes a value",
+			values:     "replicas: null\nextra: ~\n",
+			violations: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(tt *testing.T) {
+			validator := newTestValidator(tt, "name: chart\n")
+			violations, err := validator.Validate("values.yaml", parseDocument(tt, tc.values))
+			require.NoError(tt, err)
+
+			lines := []string{}
+			for _, violation := range violations {
+				lines = append(lines, violation.String())
+			}
+			assert.Equal(tt, tc.violations, lines)
+		})
+	}
+}
+
+func TestValidateRequiredDefaults(t *testing.T) {
+	t.Run("required values can be set by the chart", func(tt *testing.T) {
+		validator := newTestValidator(tt, "name: chart\n")
+		violations, err := validator.Validate("values.yaml", parseDocument(tt, "replicas: 2\n"))
+		require.NoError(tt, err)
+		assert.Empty(tt, violations)
+	})
+
+	t.Run("required values must be set by either", func(tt *testing.T) {
+		validator := newTestValidator(tt, "replicas: 1\n")
+		violations, err := validator.Validate("values.yaml", parseDocument(tt, "replicas: 2\n"))
+		require.NoError(tt, err)
+		require.Len(tt, violations, 1)
+		assert.Equal(tt, "name", violations[0].Path)
+		assert.Equal(tt, "is required", violations[0].Message)
+	})
+}